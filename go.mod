@@ -3,11 +3,10 @@ module code.cloudfoundry.org/quarks-utils
 go 1.15
 
 require (
-	github.com/cloudflare/cfssl v1.4.1
-	github.com/dchest/uniuri v0.0.0-20200228104902-7aecb25e1fe5
 	github.com/go-logr/zapr v0.2.0
 	github.com/onsi/ginkgo v1.14.1
 	github.com/onsi/gomega v1.10.2
+	github.com/pavel-v-chernykh/keystore-go/v4 v4.1.0
 	github.com/pkg/errors v0.9.1
 	github.com/spf13/afero v1.2.2
 	github.com/spf13/cobra v1.1.1
@@ -24,4 +23,5 @@ require (
 	k8s.io/client-go v0.20.2
 	k8s.io/code-generator v0.20.1
 	sigs.k8s.io/controller-runtime v0.8.2
+	software.sslmate.com/src/go-pkcs12 v0.0.0-20200830195227-52f69702a001
 )
@@ -0,0 +1,338 @@
+// Package versionedsecret provides cobra commands to inspect and manage the versions of versioned secrets
+package versionedsecret
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+
+	"code.cloudfoundry.org/quarks-utils/pkg/cmd"
+	"code.cloudfoundry.org/quarks-utils/pkg/logger"
+	"code.cloudfoundry.org/quarks-utils/pkg/versionedsecretstore"
+)
+
+// ClientsetFunc returns the clientset the commands use to talk to the cluster
+type ClientsetFunc func() (kubernetes.Interface, error)
+
+type options struct {
+	newClientset ClientsetFunc
+	namespace    string
+	output       string
+	keyFile      string
+	keySecret    string
+}
+
+// NewCommand returns the versioned-secret command, which connects to the
+// cluster using the kubeconfig flag
+func NewCommand() *cobra.Command {
+	return NewCommandWithClientset(kubeClientset)
+}
+
+// NewCommandWithClientset returns the versioned-secret command, which uses
+// the clientset returned by newClientset
+func NewCommandWithClientset(newClientset ClientsetFunc) *cobra.Command {
+	o := &options{newClientset: newClientset}
+
+	root := &cobra.Command{
+		Use:           "versioned-secret",
+		Short:         "Inspect and manage the versions of versioned secrets",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+	}
+
+	pf := root.PersistentFlags()
+	argToEnv := map[string]string{}
+	cmd.KubeConfigFlags(pf, argToEnv)
+	cmd.LoggerFlags(pf, argToEnv)
+	pf.StringVarP(&o.namespace, "namespace", "n", "default", "Namespace of the versioned secret")
+	pf.StringVarP(&o.output, "output", "o", outputTable, "Output format, one of table, json")
+	pf.StringVar(&o.keyFile, "key-file", "", "Path to a YAML key set to read and write encrypted versioned secrets")
+	pf.StringVar(&o.keySecret, "key-secret", "", "Secret with the key set to read and write encrypted versioned secrets, as [NAMESPACE/]NAME")
+	cmd.AddEnvToUsage(root, argToEnv)
+
+	root.AddCommand(
+		o.listCommand(),
+		o.latestCommand(),
+		o.diffCommand(),
+		o.rollbackCommand(),
+		o.pruneCommand(),
+	)
+
+	return root
+}
+
+func kubeClientset() (kubernetes.Interface, error) {
+	log := logger.New(cmd.LogLevel())
+	restConfig, err := cmd.KubeConfig(log)
+	if err != nil {
+		return nil, err
+	}
+
+	clientset, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return nil, errors.Wrap(err, "Couldn't create kubernetes clientset.")
+	}
+	return clientset, nil
+}
+
+func (o *options) listCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list NAME",
+		Short: "List all versions of a versioned secret",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(c *cobra.Command, args []string) error {
+			versions, err := o.versions(c, args[0])
+			if err != nil {
+				return err
+			}
+			return o.printVersions(c.OutOrStdout(), versions)
+		},
+	}
+}
+
+func (o *options) latestCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "latest NAME",
+		Short: "Show the latest version of a versioned secret",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(c *cobra.Command, args []string) error {
+			versions, err := o.versions(c, args[0])
+			if err != nil {
+				return err
+			}
+			return o.printVersions(c.OutOrStdout(), versions[len(versions)-1:])
+		},
+	}
+}
+
+func (o *options) diffCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "diff NAME VERSION [VERSION]",
+		Short: "Show the keys which differ between two versions, the second version defaults to the latest",
+		Long: `Show the keys which were added, removed or changed between two versions of a versioned secret.
+Values are not printed. The second version defaults to the latest version.`,
+		Args: cobra.RangeArgs(2, 3),
+		RunE: func(c *cobra.Command, args []string) error {
+			store, err := o.store()
+			if err != nil {
+				return err
+			}
+
+			from, err := parseVersion(args[1])
+			if err != nil {
+				return err
+			}
+
+			var to int
+			if len(args) == 3 {
+				to, err = parseVersion(args[2])
+				if err != nil {
+					return err
+				}
+			} else {
+				versions, err := o.versions(c, args[0])
+				if err != nil {
+					return err
+				}
+				to = versions[len(versions)-1].Version
+			}
+
+			ctx := commandContext(c)
+			fromSecret, err := store.Get(ctx, o.namespace, args[0], from)
+			if err != nil {
+				return errors.Wrapf(err, "failed to get version %d of versioned secret '%s/%s'", from, o.namespace, args[0])
+			}
+			toSecret, err := store.Get(ctx, o.namespace, args[0], to)
+			if err != nil {
+				return errors.Wrapf(err, "failed to get version %d of versioned secret '%s/%s'", to, o.namespace, args[0])
+			}
+
+			result := diff(fromSecret, toSecret)
+			result.From, result.To = from, to
+			return o.printDiff(c.OutOrStdout(), result)
+		},
+	}
+}
+
+func (o *options) rollbackCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "rollback NAME VERSION",
+		Short: "Create a new version of a versioned secret with the content of an older version",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(c *cobra.Command, args []string) error {
+			store, err := o.store()
+			if err != nil {
+				return err
+			}
+
+			version, err := parseVersion(args[1])
+			if err != nil {
+				return err
+			}
+
+			err = store.Rollback(commandContext(c), o.namespace, args[0], version)
+			if versionedsecretstore.IsSecretIdenticalError(err) {
+				return errors.Errorf("the latest version of versioned secret '%s/%s' already has the content of version %d", o.namespace, args[0], version)
+			}
+			if err != nil {
+				return err
+			}
+
+			versions, err := o.versions(c, args[0])
+			if err != nil {
+				return err
+			}
+			return o.printVersions(c.OutOrStdout(), versions[len(versions)-1:])
+		},
+	}
+}
+
+func (o *options) pruneCommand() *cobra.Command {
+	var keep int
+
+	c := &cobra.Command{
+		Use:   "prune NAME",
+		Short: "Delete old versions of a versioned secret",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(c *cobra.Command, args []string) error {
+			store, err := o.store()
+			if err != nil {
+				return err
+			}
+
+			deleted, err := store.Prune(commandContext(c), o.namespace, args[0], keep)
+			if err != nil {
+				return err
+			}
+			return o.printDeleted(c.OutOrStdout(), deleted)
+		},
+	}
+	c.Flags().IntVar(&keep, "keep", 3, "Number of versions to keep, including the latest version")
+
+	return c
+}
+
+func (o *options) store() (versionedsecretstore.VersionedSecretStore, error) {
+	clientset, err := o.newClientset()
+	if err != nil {
+		return nil, err
+	}
+
+	store := versionedsecretstore.NewClientsetVersionedSecretStore(clientset)
+	source, err := o.keySource(clientset)
+	if err != nil {
+		return nil, err
+	}
+	if source != nil {
+		return store.WithEncryption(source), nil
+	}
+	return store, nil
+}
+
+// keySource returns the key source configured by the key flags, or nil
+func (o *options) keySource(clientset kubernetes.Interface) (versionedsecretstore.KeySource, error) {
+	switch {
+	case o.keyFile != "" && o.keySecret != "":
+		return nil, errors.New("--key-file and --key-secret are mutually exclusive")
+	case o.keyFile != "":
+		return versionedsecretstore.NewFileKeySource(afero.NewOsFs(), o.keyFile), nil
+	case o.keySecret != "":
+		nn := types.NamespacedName{Namespace: o.namespace, Name: o.keySecret}
+		if parts := strings.SplitN(o.keySecret, "/", 2); len(parts) == 2 {
+			nn = types.NamespacedName{Namespace: parts[0], Name: parts[1]}
+		}
+		if nn.Namespace == "" || nn.Name == "" {
+			return nil, errors.Errorf("invalid key secret '%s', expected [NAMESPACE/]NAME", o.keySecret)
+		}
+		return versionedsecretstore.NewClientsetSecretKeySource(clientset, nn), nil
+	}
+	return nil, nil
+}
+
+// versions returns the versions of the secret, ordered by version. It only
+// reads the metadata, so encrypted secrets can be listed without keys.
+func (o *options) versions(c *cobra.Command, name string) ([]versionInfo, error) {
+	clientset, err := o.newClientset()
+	if err != nil {
+		return nil, err
+	}
+
+	selector := labels.Set{versionedsecretstore.LabelSecretKind: versionedsecretstore.VersionSecretKind}.String()
+	list, err := clientset.CoreV1().Secrets(o.namespace).List(commandContext(c), metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list versioned secrets in namespace '%s'", o.namespace)
+	}
+
+	versions := []versionInfo{}
+	for _, secret := range list.Items {
+		if versionedsecretstore.UnversionedName(&secret) != name {
+			continue
+		}
+
+		info, err := newVersionInfo(secret)
+		if err != nil {
+			return nil, err
+		}
+		versions = append(versions, info)
+	}
+
+	if len(versions) == 0 {
+		return nil, errors.Errorf("versioned secret '%s/%s' not found", o.namespace, name)
+	}
+
+	sort.Slice(versions, func(i, j int) bool { return versions[i].Version < versions[j].Version })
+	return versions, nil
+}
+
+func parseVersion(s string) (int, error) {
+	version, err := strconv.Atoi(s)
+	if err != nil || version < 1 {
+		return 0, errors.Errorf("invalid version '%s', expected a positive number", s)
+	}
+	return version, nil
+}
+
+func commandContext(c *cobra.Command) context.Context {
+	if ctx := c.Context(); ctx != nil {
+		return ctx
+	}
+	return context.Background()
+}
+
+// diff compares the type and the data keys of two versions
+func diff(from *corev1.Secret, to *corev1.Secret) diffResult {
+	result := diffResult{Keys: []diffEntry{}}
+
+	if from.Type != to.Type {
+		result.Type = &typeChange{From: from.Type, To: to.Type}
+	}
+
+	for k, v := range from.Data {
+		newValue, ok := to.Data[k]
+		switch {
+		case !ok:
+			result.Keys = append(result.Keys, diffEntry{Key: k, Change: changeRemoved})
+		case string(v) != string(newValue):
+			result.Keys = append(result.Keys, diffEntry{Key: k, Change: changeChanged})
+		}
+	}
+
+	for k := range to.Data {
+		if _, ok := from.Data[k]; !ok {
+			result.Keys = append(result.Keys, diffEntry{Key: k, Change: changeAdded})
+		}
+	}
+
+	sort.Slice(result.Keys, func(i, j int) bool { return result.Keys[i].Key < result.Keys[j].Key })
+	return result
+}
@@ -0,0 +1,187 @@
+package versionedsecret_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "code.cloudfoundry.org/quarks-utils/pkg/cmd/versionedsecret"
+	"code.cloudfoundry.org/quarks-utils/pkg/versionedsecretstore"
+	"code.cloudfoundry.org/quarks-utils/testing"
+)
+
+var _ = Describe("versioned-secret command", func() {
+	var (
+		clientset *fake.Clientset
+		ctx       context.Context
+	)
+
+	create := func(data map[string]string) {
+		store := versionedsecretstore.NewClientsetVersionedSecretStore(clientset)
+		err := store.CreateWithOptions(ctx, versionedsecretstore.CreateOptions{
+			Namespace:         "default",
+			Name:              "fake-secret",
+			StringData:        data,
+			SourceDescription: "created by a unit-test",
+		})
+		Expect(err).ToNot(HaveOccurred())
+	}
+
+	run := func(args ...string) (string, error) {
+		out := &bytes.Buffer{}
+		c := NewCommandWithClientset(func() (kubernetes.Interface, error) { return clientset, nil })
+		c.SetOut(out)
+		c.SetArgs(args)
+		err := c.ExecuteContext(ctx)
+		return out.String(), err
+	}
+
+	BeforeEach(func() {
+		clientset = fake.NewSimpleClientset()
+		ctx = testing.NewContext()
+
+		create(map[string]string{"password": "foo", "user": "admin"})
+		create(map[string]string{"password": "bar", "token": "abc"})
+	})
+
+	It("lists all versions as a table", func() {
+		out, err := run("list", "fake-secret")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(out).To(HavePrefix("VERSION"))
+		Expect(out).To(ContainSubstring("fake-secret-v1"))
+		Expect(out).To(ContainSubstring("fake-secret-v2"))
+		Expect(out).ToNot(ContainSubstring("foo"))
+	})
+
+	It("shows the latest version as JSON", func() {
+		out, err := run("latest", "fake-secret", "-o", "json")
+		Expect(err).ToNot(HaveOccurred())
+
+		var versions []map[string]interface{}
+		Expect(json.Unmarshal([]byte(out), &versions)).To(Succeed())
+		Expect(versions).To(HaveLen(1))
+		Expect(versions[0]["name"]).To(Equal("fake-secret-v2"))
+		Expect(versions[0]["keys"]).To(ConsistOf("password", "token"))
+	})
+
+	It("diffs two versions without printing values", func() {
+		out, err := run("diff", "fake-secret", "1", "-o", "json")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(out).To(MatchJSON(`{
+			"from": 1,
+			"to": 2,
+			"keys": [
+				{"key": "password", "change": "changed"},
+				{"key": "token", "change": "added"},
+				{"key": "user", "change": "removed"}
+			]
+		}`))
+	})
+
+	It("rolls back to an older version", func() {
+		out, err := run("rollback", "fake-secret", "1")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(out).To(ContainSubstring("fake-secret-v3"))
+
+		out, err = run("diff", "fake-secret", "1", "3")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(out).To(Equal("versions 1 and 3 are identical\n"))
+	})
+
+	It("prunes old versions", func() {
+		out, err := run("prune", "fake-secret", "--keep", "1")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(out).To(Equal("deleted fake-secret-v1\n"))
+
+		out, err = run("list", "fake-secret", "-o", "json")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(out).ToNot(ContainSubstring("fake-secret-v1"))
+	})
+
+	It("fails for unknown secrets and output formats", func() {
+		_, err := run("list", "unknown")
+		Expect(err).To(MatchError(ContainSubstring("not found")))
+
+		_, err = run("list", "fake-secret", "-o", "yaml")
+		Expect(err).To(MatchError(ContainSubstring("unknown output format")))
+	})
+
+	Context("when the versioned secret is encrypted", func() {
+		const keySet = "primary: key-1\nkeys:\n  key-1: YWFhYWFhYWFhYWFhYWFhYWFhYWFhYWFhYWFhYWFhYWE=\n"
+
+		var dir string
+
+		BeforeEach(func() {
+			_, err := clientset.CoreV1().Secrets("keys").Create(ctx, &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "key-set", Namespace: "keys"},
+				Data:       map[string][]byte{versionedsecretstore.KeySetSecretKey: []byte(keySet)},
+			}, metav1.CreateOptions{})
+			Expect(err).ToNot(HaveOccurred())
+
+			dir, err = ioutil.TempDir("", "versioned-secret")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ioutil.WriteFile(filepath.Join(dir, "keys.yml"), []byte(keySet), 0600)).To(Succeed())
+
+			source := versionedsecretstore.NewClientsetSecretKeySource(clientset, types.NamespacedName{Namespace: "keys", Name: "key-set"})
+			store := versionedsecretstore.NewClientsetVersionedSecretStore(clientset).WithEncryption(source)
+			Expect(store.CreateWithOptions(ctx, versionedsecretstore.CreateOptions{
+				Namespace:         "default",
+				Name:              "encrypted-secret",
+				StringData:        map[string]string{"password": "foo"},
+				SourceDescription: "created by a unit-test",
+			})).To(Succeed())
+			Expect(store.CreateWithOptions(ctx, versionedsecretstore.CreateOptions{
+				Namespace:         "default",
+				Name:              "encrypted-secret",
+				StringData:        map[string]string{"password": "bar"},
+				SourceDescription: "created by a unit-test",
+			})).To(Succeed())
+		})
+
+		AfterEach(func() {
+			Expect(os.RemoveAll(dir)).To(Succeed())
+		})
+
+		It("requires a key source to read the data", func() {
+			_, err := run("diff", "encrypted-secret", "1")
+			Expect(err).To(MatchError(ContainSubstring("no key source")))
+		})
+
+		It("reads the key set from a file", func() {
+			out, err := run("diff", "encrypted-secret", "1", "-o", "json", "--key-file", filepath.Join(dir, "keys.yml"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(MatchJSON(`{"from": 1, "to": 2, "keys": [{"key": "password", "change": "changed"}]}`))
+		})
+
+		It("reads the key set from a secret and encrypts rollbacks", func() {
+			_, err := run("rollback", "encrypted-secret", "1", "--key-secret", "keys/key-set")
+			Expect(err).ToNot(HaveOccurred())
+
+			secret, err := clientset.CoreV1().Secrets("default").Get(ctx, "encrypted-secret-v3", metav1.GetOptions{})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(secret.Annotations).To(HaveKey(versionedsecretstore.AnnotationEncryptionKey))
+			Expect(string(secret.Data["password"])).ToNot(Equal("foo"))
+
+			out, err := run("diff", "encrypted-secret", "1", "3", "--key-secret", "keys/key-set")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(Equal("versions 1 and 3 are identical\n"))
+		})
+
+		It("rejects both key flags", func() {
+			_, err := run("diff", "encrypted-secret", "1", "--key-file", "keys.yml", "--key-secret", "key-set")
+			Expect(err).To(MatchError(ContainSubstring("mutually exclusive")))
+		})
+	})
+})
@@ -0,0 +1,170 @@
+package versionedsecret
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/duration"
+
+	"code.cloudfoundry.org/quarks-utils/pkg/versionedsecretstore"
+)
+
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+
+	changeAdded   = "added"
+	changeRemoved = "removed"
+	changeChanged = "changed"
+
+	shortHashLength = 12
+)
+
+// versionInfo describes a version, without exposing its data
+type versionInfo struct {
+	Name              string            `json:"name"`
+	Version           int               `json:"version"`
+	Type              corev1.SecretType `json:"type"`
+	Keys              []string          `json:"keys"`
+	ContentHash       string            `json:"contentHash"`
+	Encrypted         bool              `json:"encrypted"`
+	SourceDescription string            `json:"sourceDescription,omitempty"`
+	Created           time.Time         `json:"created"`
+}
+
+func newVersionInfo(secret corev1.Secret) (versionInfo, error) {
+	version, err := versionedsecretstore.Version(secret)
+	if err != nil {
+		return versionInfo{}, err
+	}
+
+	keys := make([]string, 0, len(secret.Data))
+	for k := range secret.Data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	_, encrypted := secret.Annotations[versionedsecretstore.AnnotationEncryptionKey]
+
+	return versionInfo{
+		Name:              secret.Name,
+		Version:           version,
+		Type:              secret.Type,
+		Keys:              keys,
+		ContentHash:       versionedsecretstore.SecretContentHash(secret),
+		Encrypted:         encrypted,
+		SourceDescription: secret.Annotations[versionedsecretstore.AnnotationSourceDescription],
+		Created:           secret.CreationTimestamp.Time,
+	}, nil
+}
+
+type diffEntry struct {
+	Key    string `json:"key"`
+	Change string `json:"change"`
+}
+
+type typeChange struct {
+	From corev1.SecretType `json:"from"`
+	To   corev1.SecretType `json:"to"`
+}
+
+// diffResult lists the differences between two versions, without exposing their data
+type diffResult struct {
+	From int         `json:"from"`
+	To   int         `json:"to"`
+	Type *typeChange `json:"type,omitempty"`
+	Keys []diffEntry `json:"keys"`
+}
+
+func (o *options) printVersions(out io.Writer, versions []versionInfo) error {
+	if o.output != outputTable {
+		return o.printJSON(out, versions)
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tTYPE\tKEYS\tHASH\tENCRYPTED\tAGE")
+	for _, v := range versions {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%t\t%s\n",
+			v.Version,
+			v.Name,
+			v.Type,
+			strings.Join(v.Keys, ","),
+			shortHash(v.ContentHash),
+			v.Encrypted,
+			age(v.Created),
+		)
+	}
+	return w.Flush()
+}
+
+func (o *options) printDiff(out io.Writer, result diffResult) error {
+	if o.output != outputTable {
+		return o.printJSON(out, result)
+	}
+
+	if result.Type == nil && len(result.Keys) == 0 {
+		_, err := fmt.Fprintf(out, "versions %d and %d are identical\n", result.From, result.To)
+		return err
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	if result.Type != nil {
+		fmt.Fprintf(w, "type changed from %s to %s\n", result.Type.From, result.Type.To)
+	}
+	if len(result.Keys) > 0 {
+		fmt.Fprintln(w, "KEY\tCHANGE")
+		for _, e := range result.Keys {
+			fmt.Fprintf(w, "%s\t%s\n", e.Key, e.Change)
+		}
+	}
+	return w.Flush()
+}
+
+func (o *options) printDeleted(out io.Writer, deleted []string) error {
+	if o.output != outputTable {
+		return o.printJSON(out, deleted)
+	}
+
+	if len(deleted) == 0 {
+		_, err := fmt.Fprintln(out, "no versions deleted")
+		return err
+	}
+	for _, name := range deleted {
+		if _, err := fmt.Fprintf(out, "deleted %s\n", name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (o *options) printJSON(out io.Writer, v interface{}) error {
+	if o.output != outputJSON {
+		return errors.Errorf("unknown output format '%s', use one of %s, %s", o.output, outputTable, outputJSON)
+	}
+
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// shortHash abbreviates the hash
+func shortHash(hash string) string {
+	if len(hash) > shortHashLength {
+		return hash[:shortHashLength]
+	}
+	return hash
+}
+
+func age(created time.Time) string {
+	if created.IsZero() {
+		return "<unknown>"
+	}
+	return duration.HumanDuration(time.Since(created))
+}
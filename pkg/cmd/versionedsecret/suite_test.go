@@ -0,0 +1,13 @@
+package versionedsecret_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestVersionedSecret(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Versioned secret command Suite")
+}
@@ -0,0 +1,26 @@
+package credsgen
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// SecretData returns the credentials in the layout of kubernetes.io/basic-auth secrets
+func (b BasicAuth) SecretData() map[string][]byte {
+	return map[string][]byte{
+		corev1.BasicAuthUsernameKey: []byte(b.Username),
+		corev1.BasicAuthPasswordKey: []byte(b.Password),
+	}
+}
+
+// Secret returns a kubernetes.io/basic-auth secret with the credentials
+func (b BasicAuth) Secret(name string, namespace string) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Type: corev1.SecretTypeBasicAuth,
+		Data: b.SecretData(),
+	}
+}
@@ -0,0 +1,29 @@
+package credsgen_test
+
+import (
+	corev1 "k8s.io/api/core/v1"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"code.cloudfoundry.org/quarks-utils/pkg/credsgen"
+)
+
+var _ = Describe("BasicAuth", func() {
+	basicAuth := credsgen.BasicAuth{Username: "admin", Password: "secret"}
+
+	It("returns the data of basic auth secrets", func() {
+		Expect(basicAuth.SecretData()).To(Equal(map[string][]byte{
+			"username": []byte("admin"),
+			"password": []byte("secret"),
+		}))
+	})
+
+	It("returns a basic auth secret", func() {
+		secret := basicAuth.Secret("foo", "default")
+		Expect(secret.Name).To(Equal("foo"))
+		Expect(secret.Namespace).To(Equal("default"))
+		Expect(secret.Type).To(Equal(corev1.SecretTypeBasicAuth))
+		Expect(secret.Data).To(Equal(basicAuth.SecretData()))
+	})
+})
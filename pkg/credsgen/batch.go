@@ -0,0 +1,237 @@
+package credsgen
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// RequestType is the kind of credential of a batch request
+type RequestType string
+
+const (
+	// PasswordRequest generates a password
+	PasswordRequest RequestType = "password"
+	// CertificateRequest generates a certificate
+	CertificateRequest RequestType = "certificate"
+	// SSHKeyRequest generates an SSH key
+	SSHKeyRequest RequestType = "ssh"
+	// RSAKeyRequest generates an RSA key
+	RSAKeyRequest RequestType = "rsa"
+	// HtpasswdRequest generates an htpasswd entry
+	HtpasswdRequest RequestType = "htpasswd"
+	// BasicAuthRequest generates basic auth credentials
+	BasicAuthRequest RequestType = "basic-auth"
+	// DHParamsRequest generates Diffie-Hellman parameters
+	DHParamsRequest RequestType = "dh-params"
+)
+
+// Request is a single credential of a batch
+type Request struct {
+	// Name identifies the credential in the batch and is passed to the generator
+	Name string
+	Type RequestType
+	// Password is used for password requests
+	Password PasswordGenerationRequest
+	// Certificate is used for certificate requests
+	Certificate CertificateGenerationRequest
+	// Htpasswd is used for htpasswd requests
+	Htpasswd HtpasswdGenerationRequest
+	// BasicAuth is used for basic auth requests
+	BasicAuth BasicAuthGenerationRequest
+	// DHParams is used for DH parameters requests
+	DHParams DHParamsGenerationRequest
+	// SignedBy is the name of the certificate request in the batch, which
+	// generates the CA for this certificate. It replaces Certificate.CA.
+	SignedBy string
+}
+
+// Result holds the credential generated for a request, depending on the type of the request
+type Result struct {
+	Password    string
+	Certificate Certificate
+	SSHKey      SSHKey
+	RSAKey      RSAKey
+	Htpasswd    Htpasswd
+	BasicAuth   BasicAuth
+	DHParams    DHParams
+}
+
+// Results maps the request names to the generated credentials
+type Results map[string]Result
+
+// BatchError aggregates the errors of a batch, by request name
+type BatchError struct {
+	Errors map[string]error
+}
+
+func (e BatchError) Error() string {
+	names := make([]string, 0, len(e.Errors))
+	for name := range e.Errors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	messages := make([]string, 0, len(names))
+	for _, name := range names {
+		messages = append(messages, fmt.Sprintf("'%s': %s", name, e.Errors[name]))
+	}
+	return fmt.Sprintf("failed to generate credentials: %s", strings.Join(messages, "; "))
+}
+
+// IsBatchError returns whether the error is a BatchError
+func IsBatchError(err error) bool {
+	_, ok := err.(BatchError)
+	return ok
+}
+
+// GenerateBatch generates all credentials of the batch with the generator.
+// Certificates are generated after the CA they are signed by. It returns
+// either all results or a BatchError, which contains the errors of all failed
+// requests. Requests depending on a failed request fail, too.
+func GenerateBatch(generator Generator, requests []Request) (Results, error) {
+	order, err := resolveBatch(requests)
+	if err != nil {
+		return nil, err
+	}
+
+	results := Results{}
+	errs := map[string]error{}
+	for _, request := range order {
+		if request.SignedBy != "" {
+			if _, failed := errs[request.SignedBy]; failed {
+				errs[request.Name] = Errorf(ErrInvalidCA, "CA '%s' could not be generated", request.SignedBy)
+				continue
+			}
+		}
+
+		result, err := generate(generator, request, results)
+		if err != nil {
+			errs[request.Name] = err
+			continue
+		}
+		results[request.Name] = result
+	}
+
+	if len(errs) > 0 {
+		return nil, BatchError{Errors: errs}
+	}
+	return results, nil
+}
+
+func generate(generator Generator, request Request, results Results) (Result, error) {
+	switch request.Type {
+	case PasswordRequest:
+		return Result{Password: generator.GeneratePassword(request.Name, request.Password)}, nil
+	case CertificateRequest:
+		certRequest := request.Certificate
+		if request.SignedBy != "" {
+			certRequest.CA = results[request.SignedBy].Certificate
+		}
+		cert, err := generator.GenerateCertificate(request.Name, certRequest)
+		return Result{Certificate: cert}, err
+	case SSHKeyRequest:
+		key, err := generator.GenerateSSHKey(request.Name)
+		return Result{SSHKey: key}, err
+	case RSAKeyRequest:
+		key, err := generator.GenerateRSAKey(request.Name)
+		return Result{RSAKey: key}, err
+	case HtpasswdRequest:
+		htpasswd, err := generator.GenerateHtpasswd(request.Name, request.Htpasswd)
+		return Result{Htpasswd: htpasswd}, err
+	case BasicAuthRequest:
+		basicAuth, err := generator.GenerateBasicAuth(request.Name, request.BasicAuth)
+		return Result{BasicAuth: basicAuth}, err
+	case DHParamsRequest:
+		params, err := generator.GenerateDHParams(request.Name, request.DHParams)
+		return Result{DHParams: params}, err
+	}
+	return Result{}, Errorf(ErrInvalidRequest, "unknown request type '%s'", request.Type)
+}
+
+// resolveBatch validates the requests and orders them, so CAs are generated
+// before the certificates they sign. The order of independent requests is kept.
+func resolveBatch(requests []Request) ([]Request, error) {
+	errs := map[string]error{}
+	byName := map[string]Request{}
+	for _, request := range requests {
+		if request.Name == "" {
+			errs[""] = Errorf(ErrInvalidRequest, "request names must not be empty")
+			continue
+		}
+		if _, ok := byName[request.Name]; ok {
+			errs[request.Name] = Errorf(ErrInvalidRequest, "duplicate request name")
+			continue
+		}
+		byName[request.Name] = request
+	}
+
+	for _, request := range requests {
+		if _, ok := errs[request.Name]; ok {
+			continue
+		}
+
+		switch request.Type {
+		case PasswordRequest, CertificateRequest, SSHKeyRequest, RSAKeyRequest, HtpasswdRequest, BasicAuthRequest, DHParamsRequest:
+		default:
+			errs[request.Name] = Errorf(ErrInvalidRequest, "unknown request type '%s'", request.Type)
+			continue
+		}
+
+		if request.SignedBy == "" {
+			continue
+		}
+		if request.Type != CertificateRequest {
+			errs[request.Name] = Errorf(ErrInvalidRequest, "only certificates can be signed by a CA")
+			continue
+		}
+		ca, ok := byName[request.SignedBy]
+		if !ok {
+			errs[request.Name] = Errorf(ErrInvalidRequest, "CA '%s' is not part of the batch", request.SignedBy)
+			continue
+		}
+		if ca.Type != CertificateRequest || !ca.Certificate.IsCA {
+			errs[request.Name] = Errorf(ErrInvalidRequest, "'%s' is not a CA certificate request", request.SignedBy)
+		}
+	}
+
+	if len(errs) > 0 {
+		return nil, BatchError{Errors: errs}
+	}
+
+	order := make([]Request, 0, len(requests))
+	state := map[string]int{}
+	const (
+		visiting = 1
+		done     = 2
+	)
+
+	var visit func(request Request) error
+	visit = func(request Request) error {
+		switch state[request.Name] {
+		case done:
+			return nil
+		case visiting:
+			return Errorf(ErrInvalidRequest, "dependency cycle")
+		}
+
+		state[request.Name] = visiting
+		if request.SignedBy != "" {
+			if err := visit(byName[request.SignedBy]); err != nil {
+				return err
+			}
+		}
+		state[request.Name] = done
+		order = append(order, request)
+		return nil
+	}
+
+	for _, request := range requests {
+		if err := visit(request); err != nil {
+			errs[request.Name] = err
+			return nil, BatchError{Errors: errs}
+		}
+	}
+
+	return order, nil
+}
@@ -0,0 +1,144 @@
+package credsgen_test
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+
+	"github.com/pkg/errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"code.cloudfoundry.org/quarks-utils/pkg/credsgen"
+	"code.cloudfoundry.org/quarks-utils/pkg/credsgen/fakes"
+	inmemorygenerator "code.cloudfoundry.org/quarks-utils/pkg/credsgen/in_memory_generator"
+	helper "code.cloudfoundry.org/quarks-utils/testing/testhelper"
+)
+
+var _ = Describe("GenerateBatch", func() {
+	var (
+		generator *fakes.FakeGenerator
+		requests  []credsgen.Request
+	)
+
+	BeforeEach(func() {
+		generator = &fakes.FakeGenerator{}
+		generator.GeneratePasswordReturns("secret")
+		generator.GenerateCertificateCalls(func(name string, request credsgen.CertificateGenerationRequest) (credsgen.Certificate, error) {
+			return credsgen.Certificate{IsCA: request.IsCA, Certificate: []byte(name)}, nil
+		})
+
+		requests = []credsgen.Request{
+			{Name: "leaf", Type: credsgen.CertificateRequest, SignedBy: "intermediate"},
+			{Name: "password", Type: credsgen.PasswordRequest, Password: credsgen.PasswordGenerationRequest{Length: 10}},
+			{Name: "intermediate", Type: credsgen.CertificateRequest, SignedBy: "root", Certificate: credsgen.CertificateGenerationRequest{IsCA: true}},
+			{Name: "root", Type: credsgen.CertificateRequest, Certificate: credsgen.CertificateGenerationRequest{IsCA: true}},
+			{Name: "ssh", Type: credsgen.SSHKeyRequest},
+		}
+	})
+
+	It("generates CAs before the certificates they sign", func() {
+		results, err := credsgen.GenerateBatch(generator, requests)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(results).To(HaveLen(5))
+		Expect(results["password"].Password).To(Equal("secret"))
+
+		Expect(generator.GenerateCertificateCallCount()).To(Equal(3))
+		names := []string{}
+		for i := 0; i < 3; i++ {
+			name, _ := generator.GenerateCertificateArgsForCall(i)
+			names = append(names, name)
+		}
+		Expect(names).To(Equal([]string{"root", "intermediate", "leaf"}))
+
+		_, request := generator.GenerateCertificateArgsForCall(2)
+		Expect(request.CA.Certificate).To(Equal([]byte("intermediate")))
+	})
+
+	It("generates htpasswd entries, basic auth credentials and DH parameters", func() {
+		generator.GenerateHtpasswdReturns(credsgen.Htpasswd{Entry: "admin:hash"}, nil)
+		generator.GenerateBasicAuthReturns(credsgen.BasicAuth{Username: "admin", Password: "secret"}, nil)
+		generator.GenerateDHParamsReturns(credsgen.DHParams{Parameters: []byte("params")}, nil)
+		requests = []credsgen.Request{
+			{Name: "htpasswd", Type: credsgen.HtpasswdRequest, Htpasswd: credsgen.HtpasswdGenerationRequest{Username: "admin"}},
+			{Name: "basic-auth", Type: credsgen.BasicAuthRequest, BasicAuth: credsgen.BasicAuthGenerationRequest{Username: "admin"}},
+			{Name: "dh", Type: credsgen.DHParamsRequest, DHParams: credsgen.DHParamsGenerationRequest{Bits: 4096}},
+		}
+
+		results, err := credsgen.GenerateBatch(generator, requests)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(results["htpasswd"].Htpasswd.Entry).To(Equal("admin:hash"))
+		Expect(results["basic-auth"].BasicAuth.Password).To(Equal("secret"))
+		Expect(results["dh"].DHParams.Parameters).To(Equal([]byte("params")))
+
+		name, htpasswdRequest := generator.GenerateHtpasswdArgsForCall(0)
+		Expect(name).To(Equal("htpasswd"))
+		Expect(htpasswdRequest.Username).To(Equal("admin"))
+		_, dhRequest := generator.GenerateDHParamsArgsForCall(0)
+		Expect(dhRequest.Bits).To(Equal(4096))
+	})
+
+	It("returns no results if any request fails", func() {
+		generator.GenerateCertificateReturnsOnCall(1, credsgen.Certificate{}, errors.New("fake-error"))
+		generator.GenerateSSHKeyReturns(credsgen.SSHKey{}, errors.New("ssh-error"))
+
+		results, err := credsgen.GenerateBatch(generator, requests)
+		Expect(results).To(BeNil())
+		Expect(credsgen.IsBatchError(err)).To(BeTrue())
+
+		batchErr := err.(credsgen.BatchError)
+		Expect(batchErr.Errors).To(HaveKey("intermediate"))
+		Expect(batchErr.Errors).To(HaveKey("leaf"))
+		Expect(batchErr.Errors).To(HaveKey("ssh"))
+		Expect(batchErr.Errors).ToNot(HaveKey("root"))
+		Expect(generator.GenerateCertificateCallCount()).To(Equal(2))
+	})
+
+	It("validates the requests before generating anything", func() {
+		requests = append(requests,
+			credsgen.Request{Name: "password", Type: credsgen.PasswordRequest},
+			credsgen.Request{Name: "orphan", Type: credsgen.CertificateRequest, SignedBy: "missing"},
+			credsgen.Request{Name: "not-signed-by-ca", Type: credsgen.CertificateRequest, SignedBy: "leaf"},
+			credsgen.Request{Name: "unknown", Type: "unknown"},
+		)
+
+		_, err := credsgen.GenerateBatch(generator, requests)
+		Expect(err).To(HaveOccurred())
+		Expect(err.(credsgen.BatchError).Errors).To(HaveLen(4))
+		for _, err := range err.(credsgen.BatchError).Errors {
+			Expect(errors.Is(err, credsgen.ErrInvalidRequest)).To(BeTrue())
+		}
+		Expect(generator.Invocations()).To(BeEmpty())
+	})
+
+	It("detects dependency cycles", func() {
+		requests = []credsgen.Request{
+			{Name: "a", Type: credsgen.CertificateRequest, SignedBy: "b", Certificate: credsgen.CertificateGenerationRequest{IsCA: true}},
+			{Name: "b", Type: credsgen.CertificateRequest, SignedBy: "a", Certificate: credsgen.CertificateGenerationRequest{IsCA: true}},
+		}
+
+		_, err := credsgen.GenerateBatch(generator, requests)
+		Expect(err).To(MatchError(ContainSubstring("dependency cycle")))
+	})
+
+	It("generates a signed certificate with the in-memory generator", func() {
+		_, log := helper.NewTestLogger()
+		g := inmemorygenerator.NewInMemoryGenerator(log)
+		g.Algorithm = "ecdsa"
+		g.Bits = 256
+
+		results, err := credsgen.GenerateBatch(g, []credsgen.Request{
+			{Name: "leaf", Type: credsgen.CertificateRequest, SignedBy: "ca", Certificate: credsgen.CertificateGenerationRequest{CommonName: "leaf"}},
+			{Name: "ca", Type: credsgen.CertificateRequest, Certificate: credsgen.CertificateGenerationRequest{CommonName: "ca", IsCA: true}},
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		roots := x509.NewCertPool()
+		Expect(roots.AppendCertsFromPEM(results["ca"].Certificate.Certificate)).To(BeTrue())
+		block, _ := pem.Decode(results["leaf"].Certificate.Certificate)
+		leaf, err := x509.ParseCertificate(block.Bytes)
+		Expect(err).ToNot(HaveOccurred())
+		_, err = leaf.Verify(x509.VerifyOptions{Roots: roots})
+		Expect(err).ToNot(HaveOccurred())
+	})
+})
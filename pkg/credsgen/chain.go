@@ -0,0 +1,128 @@
+package credsgen
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/pem"
+	"time"
+)
+
+// IssuerChain returns the chain of a certificate issued by the CA, which is
+// the CA certificate followed by its own chain. Self-signed root CAs are
+// left out, so the chain is empty for certificates issued by a root CA.
+func IssuerChain(ca Certificate) ([]byte, error) {
+	certs, err := parseCertificates(append(append([]byte{}, ca.Certificate...), ca.Chain...))
+	if err != nil {
+		return nil, WrapError(ErrInvalidCA, err, "parsing CA certificate")
+	}
+
+	chain := []byte{}
+	for _, cert := range certs {
+		if isSelfSigned(cert) {
+			continue
+		}
+		chain = append(chain, encodeCertificate(cert)...)
+	}
+	if len(chain) == 0 {
+		return nil, nil
+	}
+	return chain, nil
+}
+
+// FullChain returns the PEM encoded certificate followed by its chain
+func (c Certificate) FullChain() []byte {
+	fullChain := append([]byte{}, c.Certificate...)
+	if len(c.Chain) == 0 {
+		return fullChain
+	}
+	if len(fullChain) > 0 && fullChain[len(fullChain)-1] != '\n' {
+		fullChain = append(fullChain, '\n')
+	}
+	return append(fullChain, c.Chain...)
+}
+
+// CABundle returns the PEM encoded certificates of the CAs, e.g. the old and
+// the new root CA during a rotation. Duplicate certificates are left out.
+func CABundle(cas ...Certificate) ([]byte, error) {
+	if len(cas) == 0 {
+		return nil, Errorf(ErrInvalidRequest, "CA bundle needs at least one CA")
+	}
+
+	certs, err := parseCACertificates(cas)
+	if err != nil {
+		return nil, WrapError(ErrInvalidCA, err, "parsing CA certificates")
+	}
+
+	bundle := []byte{}
+	seen := map[string]bool{}
+	for _, cert := range certs {
+		if !cert.IsCA {
+			return nil, Errorf(ErrInvalidCA, "certificate '%s' is not a CA", cert.Subject.CommonName)
+		}
+		if seen[string(cert.Raw)] {
+			continue
+		}
+		seen[string(cert.Raw)] = true
+		bundle = append(bundle, encodeCertificate(cert)...)
+	}
+	return bundle, nil
+}
+
+// VerifyChain verifies the certificate against the PEM encoded CA bundle.
+// Intermediate CAs are taken from the certificate's chain.
+func VerifyChain(cert Certificate, bundle []byte) error {
+	return verifyChain(cert, bundle, time.Now())
+}
+
+func verifyChain(cert Certificate, bundle []byte, now time.Time) error {
+	certs, err := parseCertificates(cert.Certificate)
+	if err != nil {
+		return err
+	}
+
+	roots := x509.NewCertPool()
+	rootCerts, err := parseCertificates(bundle)
+	if err != nil {
+		return WrapError(ErrInvalidCA, err, "parsing CA bundle")
+	}
+	for _, root := range rootCerts {
+		roots.AddCert(root)
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, intermediate := range certs[1:] {
+		intermediates.AddCert(intermediate)
+	}
+	if len(cert.Chain) > 0 {
+		chain, err := parseCertificates(cert.Chain)
+		if err != nil {
+			return WrapError(ErrInvalidRequest, err, "parsing certificate chain")
+		}
+		for _, intermediate := range chain {
+			intermediates.AddCert(intermediate)
+		}
+	}
+
+	_, err = certs[0].Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		CurrentTime:   now,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	})
+	if err != nil {
+		return WrapError(ErrInvalidCA, err, "verifying certificate chain")
+	}
+	return nil
+}
+
+// isSelfSigned returns true if the certificate is signed by its own key
+func isSelfSigned(cert *x509.Certificate) bool {
+	if !bytes.Equal(cert.RawIssuer, cert.RawSubject) {
+		return false
+	}
+	return cert.CheckSignature(cert.SignatureAlgorithm, cert.RawTBSCertificate, cert.Signature) == nil
+}
+
+func encodeCertificate(cert *x509.Certificate) []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})
+}
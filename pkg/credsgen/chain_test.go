@@ -0,0 +1,137 @@
+package credsgen_test
+
+import (
+	"bytes"
+	"encoding/pem"
+
+	"github.com/pkg/errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"code.cloudfoundry.org/quarks-utils/pkg/credsgen"
+	inmemorygenerator "code.cloudfoundry.org/quarks-utils/pkg/credsgen/in_memory_generator"
+	helper "code.cloudfoundry.org/quarks-utils/testing/testhelper"
+)
+
+var _ = Describe("Chain", func() {
+	var (
+		generator    *inmemorygenerator.InMemoryGenerator
+		root         credsgen.Certificate
+		newRoot      credsgen.Certificate
+		intermediate credsgen.Certificate
+		leaf         credsgen.Certificate
+	)
+
+	generate := func(name string, request credsgen.CertificateGenerationRequest) credsgen.Certificate {
+		cert, err := generator.GenerateCertificate(name, request)
+		Expect(err).ToNot(HaveOccurred())
+		return cert
+	}
+
+	countCertificates := func(data []byte) int {
+		count := 0
+		for block, rest := pem.Decode(data); block != nil; block, rest = pem.Decode(rest) {
+			count++
+		}
+		return count
+	}
+
+	BeforeEach(func() {
+		_, log := helper.NewTestLogger()
+		generator = inmemorygenerator.NewInMemoryGenerator(log)
+		generator.Algorithm = "ecdsa"
+		generator.Bits = 256
+
+		root = generate("root", credsgen.CertificateGenerationRequest{CommonName: "Root CA", IsCA: true})
+		newRoot = generate("new-root", credsgen.CertificateGenerationRequest{CommonName: "New Root CA", IsCA: true})
+		intermediate = generate("intermediate", credsgen.CertificateGenerationRequest{CommonName: "Intermediate CA", IsCA: true, CA: root})
+		leaf = generate("leaf", credsgen.CertificateGenerationRequest{CommonName: "example.com", CA: intermediate})
+	})
+
+	Describe("IssuerChain", func() {
+		It("is empty for root CAs", func() {
+			chain, err := credsgen.IssuerChain(root)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(chain).To(BeEmpty())
+		})
+
+		It("contains the intermediate CA and its chain", func() {
+			chain, err := credsgen.IssuerChain(intermediate)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(chain).To(Equal(intermediate.Certificate))
+
+			// a CA stored with its root, e.g. from a full chain PEM
+			intermediate.Certificate = append(intermediate.Certificate, root.Certificate...)
+			chain, err = credsgen.IssuerChain(intermediate)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(countCertificates(chain)).To(Equal(1))
+		})
+
+		It("fails for invalid CAs", func() {
+			_, err := credsgen.IssuerChain(credsgen.Certificate{})
+			Expect(errors.Is(err, credsgen.ErrInvalidCA)).To(BeTrue())
+		})
+	})
+
+	Describe("FullChain", func() {
+		It("appends the chain to the certificate", func() {
+			fullChain := leaf.FullChain()
+			Expect(countCertificates(fullChain)).To(Equal(2))
+			Expect(bytes.HasPrefix(fullChain, leaf.Certificate)).To(BeTrue())
+			Expect(bytes.HasSuffix(fullChain, intermediate.Certificate)).To(BeTrue())
+		})
+
+		It("returns the certificate if there is no chain", func() {
+			Expect(root.FullChain()).To(Equal(root.Certificate))
+		})
+	})
+
+	Describe("CABundle", func() {
+		It("contains the CAs once", func() {
+			bundle, err := credsgen.CABundle(root, newRoot, root)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(countCertificates(bundle)).To(Equal(2))
+		})
+
+		It("fails for certificates which are not CAs", func() {
+			_, err := credsgen.CABundle(root, leaf)
+			Expect(err).To(MatchError(ContainSubstring("certificate 'example.com' is not a CA")))
+			Expect(errors.Is(err, credsgen.ErrInvalidCA)).To(BeTrue())
+		})
+
+		It("fails without CAs", func() {
+			_, err := credsgen.CABundle()
+			Expect(errors.Is(err, credsgen.ErrInvalidRequest)).To(BeTrue())
+		})
+	})
+
+	Describe("VerifyChain", func() {
+		It("verifies the leaf against a bundle during a root CA rotation", func() {
+			oldBundle, err := credsgen.CABundle(root)
+			Expect(err).ToNot(HaveOccurred())
+			rotationBundle, err := credsgen.CABundle(newRoot, root)
+			Expect(err).ToNot(HaveOccurred())
+			newBundle, err := credsgen.CABundle(newRoot)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(credsgen.VerifyChain(leaf, oldBundle)).To(Succeed())
+			Expect(credsgen.VerifyChain(leaf, rotationBundle)).To(Succeed())
+
+			err = credsgen.VerifyChain(leaf, newBundle)
+			Expect(err).To(MatchError(ContainSubstring("verifying certificate chain")))
+			Expect(errors.Is(err, credsgen.ErrInvalidCA)).To(BeTrue())
+		})
+
+		It("fails without the intermediate CA", func() {
+			leaf.Chain = nil
+			Expect(credsgen.VerifyChain(leaf, root.Certificate)).ToNot(Succeed())
+		})
+
+		It("uses intermediates of a full chain certificate", func() {
+			leaf.Certificate = leaf.FullChain()
+			leaf.Chain = nil
+			Expect(credsgen.VerifyChain(leaf, root.Certificate)).To(Succeed())
+		})
+	})
+})
@@ -0,0 +1,158 @@
+package credsgen
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"code.cloudfoundry.org/quarks-utils/pkg/ctxlog"
+)
+
+// ContextGenerator provides an interface for generating credentials like
+// Generator. The context cancels the generation and carries the logger.
+type ContextGenerator interface {
+	GeneratePassword(ctx context.Context, name string, request PasswordGenerationRequest) (string, error)
+	GenerateCertificate(ctx context.Context, name string, request CertificateGenerationRequest) (Certificate, error)
+	GenerateCertificateSigningRequest(ctx context.Context, request CertificateGenerationRequest) ([]byte, []byte, error)
+	GenerateSSHKey(ctx context.Context, name string) (SSHKey, error)
+	GenerateRSAKey(ctx context.Context, name string) (RSAKey, error)
+	GenerateHtpasswd(ctx context.Context, name string, request HtpasswdGenerationRequest) (Htpasswd, error)
+	GenerateBasicAuth(ctx context.Context, name string, request BasicAuthGenerationRequest) (BasicAuth, error)
+	GenerateDHParams(ctx context.Context, name string, request DHParamsGenerationRequest) (DHParams, error)
+}
+
+// NewContextGenerator adapts a Generator to the ContextGenerator interface.
+// Calls return as soon as the context is done, but the generator can't be
+// interrupted and finishes the abandoned credential in the background.
+func NewContextGenerator(generator Generator) ContextGenerator {
+	return contextGenerator{generator: generator}
+}
+
+type contextGenerator struct {
+	generator Generator
+}
+
+var _ ContextGenerator = contextGenerator{}
+
+// GeneratePassword generates a password
+func (g contextGenerator) GeneratePassword(ctx context.Context, name string, request PasswordGenerationRequest) (string, error) {
+	ctxlog.Debugf(ctx, "Generating password '%s'", name)
+	r, err := run(ctx, func() (interface{}, error) {
+		return g.generator.GeneratePassword(name, request), nil
+	})
+	if err != nil {
+		return "", errors.Wrapf(err, "generating password '%s'", name)
+	}
+	return r.(string), nil
+}
+
+// GenerateCertificate generates a certificate
+func (g contextGenerator) GenerateCertificate(ctx context.Context, name string, request CertificateGenerationRequest) (Certificate, error) {
+	ctxlog.Debugf(ctx, "Generating certificate '%s'", name)
+	r, err := run(ctx, func() (interface{}, error) {
+		return g.generator.GenerateCertificate(name, request)
+	})
+	if err != nil {
+		return Certificate{}, errors.Wrapf(err, "generating certificate '%s'", name)
+	}
+	return r.(Certificate), nil
+}
+
+// GenerateCertificateSigningRequest generates a certificate signing request and private key
+func (g contextGenerator) GenerateCertificateSigningRequest(ctx context.Context, request CertificateGenerationRequest) ([]byte, []byte, error) {
+	ctxlog.Debugf(ctx, "Generating certificate signing request for '%s'", request.CommonName)
+	r, err := run(ctx, func() (interface{}, error) {
+		csr, key, err := g.generator.GenerateCertificateSigningRequest(request)
+		return [2][]byte{csr, key}, err
+	})
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "generating certificate signing request for '%s'", request.CommonName)
+	}
+	pair := r.([2][]byte)
+	return pair[0], pair[1], nil
+}
+
+// GenerateSSHKey generates an SSH key
+func (g contextGenerator) GenerateSSHKey(ctx context.Context, name string) (SSHKey, error) {
+	ctxlog.Debugf(ctx, "Generating SSH key '%s'", name)
+	r, err := run(ctx, func() (interface{}, error) {
+		return g.generator.GenerateSSHKey(name)
+	})
+	if err != nil {
+		return SSHKey{}, errors.Wrapf(err, "generating SSH key '%s'", name)
+	}
+	return r.(SSHKey), nil
+}
+
+// GenerateRSAKey generates an RSA key
+func (g contextGenerator) GenerateRSAKey(ctx context.Context, name string) (RSAKey, error) {
+	ctxlog.Debugf(ctx, "Generating RSA key '%s'", name)
+	r, err := run(ctx, func() (interface{}, error) {
+		return g.generator.GenerateRSAKey(name)
+	})
+	if err != nil {
+		return RSAKey{}, errors.Wrapf(err, "generating RSA key '%s'", name)
+	}
+	return r.(RSAKey), nil
+}
+
+// GenerateHtpasswd generates an htpasswd entry
+func (g contextGenerator) GenerateHtpasswd(ctx context.Context, name string, request HtpasswdGenerationRequest) (Htpasswd, error) {
+	ctxlog.Debugf(ctx, "Generating htpasswd entry '%s'", name)
+	r, err := run(ctx, func() (interface{}, error) {
+		return g.generator.GenerateHtpasswd(name, request)
+	})
+	if err != nil {
+		return Htpasswd{}, errors.Wrapf(err, "generating htpasswd entry '%s'", name)
+	}
+	return r.(Htpasswd), nil
+}
+
+// GenerateBasicAuth generates basic auth credentials
+func (g contextGenerator) GenerateBasicAuth(ctx context.Context, name string, request BasicAuthGenerationRequest) (BasicAuth, error) {
+	ctxlog.Debugf(ctx, "Generating basic auth credentials '%s'", name)
+	r, err := run(ctx, func() (interface{}, error) {
+		return g.generator.GenerateBasicAuth(name, request)
+	})
+	if err != nil {
+		return BasicAuth{}, errors.Wrapf(err, "generating basic auth credentials '%s'", name)
+	}
+	return r.(BasicAuth), nil
+}
+
+// GenerateDHParams generates Diffie-Hellman parameters
+func (g contextGenerator) GenerateDHParams(ctx context.Context, name string, request DHParamsGenerationRequest) (DHParams, error) {
+	ctxlog.Debugf(ctx, "Generating DH parameters '%s'", name)
+	r, err := run(ctx, func() (interface{}, error) {
+		return g.generator.GenerateDHParams(name, request)
+	})
+	if err != nil {
+		return DHParams{}, errors.Wrapf(err, "generating DH parameters '%s'", name)
+	}
+	return r.(DHParams), nil
+}
+
+type result struct {
+	value interface{}
+	err   error
+}
+
+// run calls generate in a goroutine and waits for its result, or until the context is done
+func run(ctx context.Context, generate func() (interface{}, error)) (interface{}, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	done := make(chan result, 1)
+	go func() {
+		value, err := generate()
+		done <- result{value: value, err: err}
+	}()
+
+	select {
+	case r := <-done:
+		return r.value, r.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
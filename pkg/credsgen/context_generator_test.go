@@ -0,0 +1,101 @@
+package credsgen_test
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"code.cloudfoundry.org/quarks-utils/pkg/credsgen"
+	"code.cloudfoundry.org/quarks-utils/pkg/credsgen/fakes"
+	"code.cloudfoundry.org/quarks-utils/pkg/ctxlog"
+	helper "code.cloudfoundry.org/quarks-utils/testing/testhelper"
+)
+
+var _ = Describe("ContextGenerator", func() {
+	var (
+		ctx       context.Context
+		fake      *fakes.FakeGenerator
+		generator credsgen.ContextGenerator
+	)
+
+	BeforeEach(func() {
+		_, log := helper.NewTestLogger()
+		ctx = ctxlog.NewParentContext(log)
+		fake = &fakes.FakeGenerator{}
+		generator = credsgen.NewContextGenerator(fake)
+	})
+
+	It("calls the wrapped generator", func() {
+		fake.GeneratePasswordReturns("secret")
+		fake.GenerateCertificateReturns(credsgen.Certificate{Certificate: []byte("cert")}, nil)
+		fake.GenerateCertificateSigningRequestReturns([]byte("csr"), []byte("key"), nil)
+		fake.GenerateSSHKeyReturns(credsgen.SSHKey{Fingerprint: "ssh"}, nil)
+		fake.GenerateRSAKeyReturns(credsgen.RSAKey{PublicKey: []byte("rsa")}, nil)
+
+		password, err := generator.GeneratePassword(ctx, "password", credsgen.PasswordGenerationRequest{Length: 10})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(password).To(Equal("secret"))
+		name, request := fake.GeneratePasswordArgsForCall(0)
+		Expect(name).To(Equal("password"))
+		Expect(request.Length).To(Equal(10))
+
+		cert, err := generator.GenerateCertificate(ctx, "cert", credsgen.CertificateGenerationRequest{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(cert.Certificate).To(Equal([]byte("cert")))
+
+		csr, key, err := generator.GenerateCertificateSigningRequest(ctx, credsgen.CertificateGenerationRequest{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(csr).To(Equal([]byte("csr")))
+		Expect(key).To(Equal([]byte("key")))
+
+		sshKey, err := generator.GenerateSSHKey(ctx, "ssh")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(sshKey.Fingerprint).To(Equal("ssh"))
+
+		rsaKey, err := generator.GenerateRSAKey(ctx, "rsa")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(rsaKey.PublicKey).To(Equal([]byte("rsa")))
+
+		fake.GenerateBasicAuthReturns(credsgen.BasicAuth{Username: "admin"}, nil)
+		basicAuth, err := generator.GenerateBasicAuth(ctx, "basic-auth", credsgen.BasicAuthGenerationRequest{Username: "admin"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(basicAuth.Username).To(Equal("admin"))
+		name, basicAuthRequest := fake.GenerateBasicAuthArgsForCall(0)
+		Expect(name).To(Equal("basic-auth"))
+		Expect(basicAuthRequest.Username).To(Equal("admin"))
+	})
+
+	It("returns the errors of the wrapped generator", func() {
+		fake.GenerateRSAKeyReturns(credsgen.RSAKey{}, errors.New("boom"))
+
+		_, err := generator.GenerateRSAKey(ctx, "rsa")
+		Expect(err).To(MatchError("generating RSA key 'rsa': boom"))
+	})
+
+	It("does not generate if the context is done", func() {
+		ctx, cancel := context.WithCancel(ctx)
+		cancel()
+
+		_, err := generator.GenerateCertificate(ctx, "cert", credsgen.CertificateGenerationRequest{})
+		Expect(errors.Cause(err)).To(Equal(context.Canceled))
+		Expect(fake.GenerateCertificateCallCount()).To(BeZero())
+	})
+
+	It("returns when the context is done during generation", func() {
+		ctx, cancel := context.WithCancel(ctx)
+		release := make(chan struct{})
+		defer close(release)
+		fake.GenerateSSHKeyStub = func(string) (credsgen.SSHKey, error) {
+			cancel()
+			<-release
+			return credsgen.SSHKey{}, nil
+		}
+
+		_, err := generator.GenerateSSHKey(ctx, "ssh")
+		Expect(err).To(MatchError(ContainSubstring("generating SSH key 'ssh'")))
+		Expect(errors.Cause(err)).To(Equal(context.Canceled))
+	})
+})
@@ -0,0 +1,156 @@
+package credsgen
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"time"
+)
+
+// DefaultCRLValidity is the validity of a CRL, if the request does not specify one
+const DefaultCRLValidity = 7 * 24 * time.Hour
+
+// CRLGenerationRequest specifies the generation parameters for certificate revocation lists
+type CRLGenerationRequest struct {
+	// CA signs the CRL, its certificate needs the "crl sign" usage
+	CA Certificate
+	// RevokedSerials are the serial numbers of the revoked certificates
+	RevokedSerials []*big.Int
+	// Number is the CRL number, which has to increase with every CRL of the
+	// CA. It defaults to the unix time of ThisUpdate.
+	Number *big.Int
+	// ThisUpdate is the issue date of the CRL and the revocation time of
+	// the certificates, it defaults to the current time
+	ThisUpdate time.Time
+	// Validity is the time until the next CRL is issued, it defaults to DefaultCRLValidity
+	Validity time.Duration
+}
+
+// SerialNumber returns the serial number of the certificate
+func (c Certificate) SerialNumber() (*big.Int, error) {
+	certs, err := parseCertificates(c.Certificate)
+	if err != nil {
+		return nil, err
+	}
+	return certs[0].SerialNumber, nil
+}
+
+// GenerateCRL returns a PEM encoded CRL, which revokes the serials and is signed by the CA
+func GenerateCRL(request CRLGenerationRequest) ([]byte, error) {
+	caCerts, err := parseCertificates(request.CA.Certificate)
+	if err != nil {
+		return nil, WrapError(ErrInvalidCA, err, "parsing CA certificate")
+	}
+	key, err := parsePrivateKey(request.CA.PrivateKey)
+	if err != nil {
+		return nil, WrapError(ErrInvalidCA, err, "parsing CA private key")
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, Errorf(ErrInvalidCA, "unsupported CA private key type %T", key)
+	}
+
+	thisUpdate := request.ThisUpdate
+	if thisUpdate.IsZero() {
+		thisUpdate = time.Now()
+	}
+	thisUpdate = thisUpdate.UTC()
+
+	validity := request.Validity
+	if validity == 0 {
+		validity = DefaultCRLValidity
+	}
+	if validity < 0 {
+		return nil, Errorf(ErrInvalidRequest, "invalid CRL validity '%s'", validity)
+	}
+
+	number := request.Number
+	if number == nil {
+		number = big.NewInt(thisUpdate.Unix())
+	}
+
+	template := &x509.RevocationList{
+		Number:     number,
+		ThisUpdate: thisUpdate,
+		NextUpdate: thisUpdate.Add(validity),
+	}
+	for _, serial := range request.RevokedSerials {
+		if serial == nil {
+			return nil, Errorf(ErrInvalidRequest, "revoked serial number must not be empty")
+		}
+		template.RevokedCertificates = append(template.RevokedCertificates, pkix.RevokedCertificate{
+			SerialNumber:   serial,
+			RevocationTime: thisUpdate,
+		})
+	}
+
+	der, err := x509.CreateRevocationList(rand.Reader, template, caCerts[0], signer)
+	if err != nil {
+		return nil, WrapError(ErrInvalidCA, err, "creating CRL")
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "X509 CRL", Bytes: der}), nil
+}
+
+// CRLVerifier checks certificates against certificate revocation lists
+type CRLVerifier struct {
+	// Clock returns the current time, which is compared to the next update
+	// of the CRL. It defaults to time.Now.
+	Clock func() time.Time
+}
+
+// IsRevoked checks the certificate against a PEM encoded CRL of the CA, at
+// the current time
+func IsRevoked(cert Certificate, crlPEM []byte, ca Certificate) (bool, error) {
+	return CRLVerifier{}.IsRevoked(cert, crlPEM, ca)
+}
+
+// IsRevoked checks the certificate against a PEM encoded CRL of the CA. It
+// fails if the CRL is not signed by the CA, if it is outdated or if the
+// certificate was not issued by the CA.
+func (v CRLVerifier) IsRevoked(cert Certificate, crlPEM []byte, ca Certificate) (bool, error) {
+	certs, err := parseCertificates(cert.Certificate)
+	if err != nil {
+		return false, WrapError(ErrInvalidRequest, err, "parsing certificate")
+	}
+	caCerts, err := parseCertificates(ca.Certificate)
+	if err != nil {
+		return false, WrapError(ErrInvalidCA, err, "parsing CA certificate")
+	}
+
+	block, _ := pem.Decode(crlPEM)
+	if block == nil || block.Type != "X509 CRL" {
+		return false, Errorf(ErrInvalidRequest, "decoding CRL PEM")
+	}
+	crl, err := x509.ParseCRL(block.Bytes)
+	if err != nil {
+		return false, WrapError(ErrInvalidRequest, err, "parsing CRL")
+	}
+
+	if err := caCerts[0].CheckCRLSignature(crl); err != nil {
+		return false, WrapError(ErrInvalidCA, err, "CRL is not signed by the CA")
+	}
+	if crl.HasExpired(v.now()) {
+		return false, Errorf(ErrInvalidRequest, "CRL expired at %s", crl.TBSCertList.NextUpdate)
+	}
+	if err := certs[0].CheckSignatureFrom(caCerts[0]); err != nil {
+		return false, WrapError(ErrInvalidCA, err, "certificate is not issued by the CA")
+	}
+
+	for _, revoked := range crl.TBSCertList.RevokedCertificates {
+		if revoked.SerialNumber.Cmp(certs[0].SerialNumber) == 0 {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// now returns the current time of the verifier's clock
+func (v CRLVerifier) now() time.Time {
+	if v.Clock == nil {
+		return time.Now()
+	}
+	return v.Clock()
+}
@@ -0,0 +1,148 @@
+package credsgen_test
+
+import (
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"code.cloudfoundry.org/quarks-utils/pkg/credsgen"
+	inmemorygenerator "code.cloudfoundry.org/quarks-utils/pkg/credsgen/in_memory_generator"
+	helper "code.cloudfoundry.org/quarks-utils/testing/testhelper"
+)
+
+var _ = Describe("CRL", func() {
+	var (
+		generator *inmemorygenerator.InMemoryGenerator
+		ca        credsgen.Certificate
+		cert      credsgen.Certificate
+		other     credsgen.Certificate
+		serial    *big.Int
+	)
+
+	BeforeEach(func() {
+		_, log := helper.NewTestLogger()
+		generator = inmemorygenerator.NewInMemoryGenerator(log)
+		generator.Algorithm = "ecdsa"
+		generator.Bits = 256
+
+		var err error
+		ca, err = generator.GenerateCertificate("ca", credsgen.CertificateGenerationRequest{CommonName: "Example CA", IsCA: true})
+		Expect(err).ToNot(HaveOccurred())
+		cert, err = generator.GenerateCertificate("leaf", credsgen.CertificateGenerationRequest{CommonName: "example.com", CA: ca})
+		Expect(err).ToNot(HaveOccurred())
+		other, err = generator.GenerateCertificate("other", credsgen.CertificateGenerationRequest{CommonName: "other.example.com", CA: ca})
+		Expect(err).ToNot(HaveOccurred())
+
+		serial, err = cert.SerialNumber()
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	Describe("GenerateCRL", func() {
+		It("generates a CRL signed by the CA", func() {
+			thisUpdate := time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC)
+			crl, err := credsgen.GenerateCRL(credsgen.CRLGenerationRequest{
+				CA:             ca,
+				RevokedSerials: []*big.Int{serial},
+				Number:         big.NewInt(3),
+				ThisUpdate:     thisUpdate,
+				Validity:       time.Hour,
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			block, _ := pem.Decode(crl)
+			Expect(block.Type).To(Equal("X509 CRL"))
+			parsed, err := x509.ParseCRL(block.Bytes)
+			Expect(err).ToNot(HaveOccurred())
+
+			tbs := parsed.TBSCertList
+			Expect(tbs.Issuer.String()).To(Equal("CN=Example CA"))
+			Expect(tbs.ThisUpdate).To(Equal(thisUpdate))
+			Expect(tbs.NextUpdate).To(Equal(thisUpdate.Add(time.Hour)))
+			Expect(tbs.RevokedCertificates).To(HaveLen(1))
+			Expect(tbs.RevokedCertificates[0].SerialNumber).To(Equal(serial))
+
+			var number *big.Int
+			for _, ext := range tbs.Extensions {
+				if ext.Id.Equal(asn1.ObjectIdentifier{2, 5, 29, 20}) {
+					_, err := asn1.Unmarshal(ext.Value, &number)
+					Expect(err).ToNot(HaveOccurred())
+				}
+			}
+			Expect(number).To(Equal(big.NewInt(3)))
+		})
+
+		It("fails if the CA can't sign CRLs", func() {
+			_, err := credsgen.GenerateCRL(credsgen.CRLGenerationRequest{CA: cert})
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("fails for negative validities", func() {
+			_, err := credsgen.GenerateCRL(credsgen.CRLGenerationRequest{CA: ca, Validity: -time.Hour})
+			Expect(err).To(MatchError(ContainSubstring("invalid CRL validity")))
+		})
+	})
+
+	Describe("IsRevoked", func() {
+		var crl []byte
+
+		BeforeEach(func() {
+			var err error
+			crl, err = credsgen.GenerateCRL(credsgen.CRLGenerationRequest{CA: ca, RevokedSerials: []*big.Int{serial}})
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("finds revoked certificates", func() {
+			revoked, err := credsgen.IsRevoked(cert, crl, ca)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(revoked).To(BeTrue())
+
+			revoked, err = credsgen.IsRevoked(other, crl, ca)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(revoked).To(BeFalse())
+		})
+
+		It("fails for CRLs of other CAs", func() {
+			otherCA, err := generator.GenerateCertificate("ca", credsgen.CertificateGenerationRequest{CommonName: "Other CA", IsCA: true})
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = credsgen.IsRevoked(cert, crl, otherCA)
+			Expect(err).To(MatchError(ContainSubstring("not signed by the CA")))
+			Expect(errors.Is(err, credsgen.ErrInvalidCA)).To(BeTrue())
+		})
+
+		It("fails for expired CRLs", func() {
+			crl, err := credsgen.GenerateCRL(credsgen.CRLGenerationRequest{
+				CA:         ca,
+				ThisUpdate: time.Now().Add(-2 * time.Hour),
+				Validity:   time.Hour,
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = credsgen.IsRevoked(cert, crl, ca)
+			Expect(err).To(MatchError(ContainSubstring("CRL expired")))
+			Expect(errors.Is(err, credsgen.ErrInvalidRequest)).To(BeTrue())
+		})
+
+		It("uses the clock of the verifier", func() {
+			verifier := credsgen.CRLVerifier{Clock: func() time.Time { return time.Now().Add(2 * credsgen.DefaultCRLValidity) }}
+			_, err := verifier.IsRevoked(cert, crl, ca)
+			Expect(err).To(MatchError(ContainSubstring("CRL expired")))
+
+			verifier.Clock = time.Now
+			revoked, err := verifier.IsRevoked(cert, crl, ca)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(revoked).To(BeTrue())
+		})
+
+		It("fails for invalid CRLs", func() {
+			_, err := credsgen.IsRevoked(cert, []byte("not a CRL"), ca)
+			Expect(errors.Is(err, credsgen.ErrInvalidRequest)).To(BeTrue())
+		})
+	})
+})
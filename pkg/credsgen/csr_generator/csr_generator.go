@@ -0,0 +1,209 @@
+// Package csrgenerator implements a credsgen.Generator, which lets a
+// Kubernetes signer issue the certificates via CertificateSigningRequests
+package csrgenerator
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	certv1 "k8s.io/api/certificates/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+
+	"code.cloudfoundry.org/quarks-utils/pkg/credsgen"
+	inmemorygenerator "code.cloudfoundry.org/quarks-utils/pkg/credsgen/in_memory_generator"
+	"code.cloudfoundry.org/quarks-utils/pkg/names"
+)
+
+const (
+	// DefaultInterval is the default interval for polling the issued certificate
+	DefaultInterval = time.Second
+	// DefaultTimeout is the default time to wait for the issued certificate
+	DefaultTimeout = 5 * time.Minute
+
+	approvalReason = "AutoApproved"
+
+	// maxGenerateNameLength leaves room for the random suffix of five
+	// characters, which the API server appends to generated names
+	maxGenerateNameLength = 247
+)
+
+// CSRGenerator represents a secret generator, which submits
+// certificates.k8s.io/v1 CertificateSigningRequests for leaf certificates
+// and waits for the signer of the cluster to issue them. Everything else,
+// including CAs and the private keys of the certificates, is generated by
+// the embedded InMemoryGenerator.
+type CSRGenerator struct {
+	*inmemorygenerator.InMemoryGenerator
+
+	SignerName  string            // Name of the Kubernetes signer
+	Usages      []certv1.KeyUsage // Requested key usages
+	AutoApprove bool              // Approve the CSRs, requires the 'approve' permission for the signer
+	Interval    time.Duration     // Interval for polling the issued certificate
+	Timeout     time.Duration     // Time to wait for the issued certificate
+
+	client kubernetes.Interface
+	log    *zap.SugaredLogger
+}
+
+var _ credsgen.Generator = &CSRGenerator{}
+
+// NewCSRGenerator creates a CSRGenerator for the signer, which auto-approves its CSRs
+func NewCSRGenerator(log *zap.SugaredLogger, client kubernetes.Interface, signerName string) *CSRGenerator {
+	return &CSRGenerator{
+		InMemoryGenerator: inmemorygenerator.NewInMemoryGenerator(log),
+		SignerName:        signerName,
+		Usages: []certv1.KeyUsage{
+			certv1.UsageDigitalSignature,
+			certv1.UsageKeyEncipherment,
+			certv1.UsageServerAuth,
+			certv1.UsageClientAuth,
+		},
+		AutoApprove: true,
+		Interval:    DefaultInterval,
+		Timeout:     DefaultTimeout,
+		client:      client,
+		log:         log,
+	}
+}
+
+// GenerateCertificate generates a certificate, which is issued by the
+// signer of the cluster. The CA of the request is ignored. CAs can't be
+// issued by the cluster and are generated in memory.
+func (g CSRGenerator) GenerateCertificate(name string, request credsgen.CertificateGenerationRequest) (credsgen.Certificate, error) {
+	if request.IsCA {
+		return g.InMemoryGenerator.GenerateCertificate(name, request)
+	}
+
+	g.log.Debugf("Generating certificate %s with signer %s", name, g.SignerName)
+
+	csReq, privateKey, err := g.GenerateCertificateSigningRequest(request)
+	if err != nil {
+		return credsgen.Certificate{}, errors.Wrap(err, "Generating certificate signing request failed.")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), g.Timeout)
+	defer cancel()
+
+	csr, err := g.create(ctx, name, csReq)
+	if err != nil {
+		return credsgen.Certificate{}, err
+	}
+	csrName := csr.Name
+	defer g.delete(csrName)
+
+	if err := g.approve(ctx, csr); err != nil {
+		return credsgen.Certificate{}, err
+	}
+
+	certificate, err := g.waitForCertificate(ctx, csrName)
+	if err != nil {
+		return credsgen.Certificate{}, err
+	}
+
+	if _, err := tls.X509KeyPair(certificate, privateKey); err != nil {
+		return credsgen.Certificate{}, errors.Wrapf(err, "certificate issued for CSR '%s' does not match the private key", csrName)
+	}
+
+	return credsgen.Certificate{
+		IsCA:        false,
+		Certificate: certificate,
+		PrivateKey:  privateKey,
+	}, nil
+}
+
+// create creates a CSR with a unique name, which is generated from the
+// name of the certificate. CSRs are cluster-scoped, so certificates of the
+// same name in different namespaces must not share a CSR.
+func (g CSRGenerator) create(ctx context.Context, name string, csReq []byte) (*certv1.CertificateSigningRequest, error) {
+	csr := &certv1.CertificateSigningRequest{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: names.TruncateMD5(names.SanitizeSubdomain(name), maxGenerateNameLength) + "-",
+		},
+		Spec: certv1.CertificateSigningRequestSpec{
+			Request:    csReq,
+			SignerName: g.SignerName,
+			Usages:     g.Usages,
+		},
+	}
+
+	created, err := g.client.CertificatesV1().CertificateSigningRequests().Create(ctx, csr, metav1.CreateOptions{})
+	if err != nil {
+		return nil, credsgen.WrapError(credsgen.ErrBackendUnavailable, err, fmt.Sprintf("creating CSR for certificate '%s'", name))
+	}
+	return created, nil
+}
+
+// approve approves the CSR, if enabled
+func (g CSRGenerator) approve(ctx context.Context, csr *certv1.CertificateSigningRequest) error {
+	if !g.AutoApprove {
+		return nil
+	}
+
+	csr.Status.Conditions = append(csr.Status.Conditions, certv1.CertificateSigningRequestCondition{
+		Type:           certv1.CertificateApproved,
+		Status:         corev1.ConditionTrue,
+		Reason:         approvalReason,
+		Message:        "This CSR was approved by the quarks credentials generator",
+		LastUpdateTime: metav1.Now(),
+	})
+	if _, err := g.client.CertificatesV1().CertificateSigningRequests().UpdateApproval(ctx, csr.Name, csr, metav1.UpdateOptions{}); err != nil {
+		return credsgen.WrapError(credsgen.ErrBackendUnavailable, err, fmt.Sprintf("approving CSR '%s'", csr.Name))
+	}
+	return nil
+}
+
+// delete removes a CSR created by the generator. The certificate is
+// returned to the caller, so the CSR is not needed anymore, even if it
+// failed. Errors are only logged, the cluster garbage collects CSRs
+// eventually.
+func (g CSRGenerator) delete(name string) {
+	ctx, cancel := context.WithTimeout(context.Background(), g.Timeout)
+	defer cancel()
+
+	err := g.client.CertificatesV1().CertificateSigningRequests().Delete(ctx, name, metav1.DeleteOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		g.log.Infof("Failed to delete CSR '%s': %v", name, err)
+	}
+}
+
+// waitForCertificate polls the CSR until the certificate is issued, or the CSR is denied or failed
+func (g CSRGenerator) waitForCertificate(ctx context.Context, name string) ([]byte, error) {
+	var certificate []byte
+	err := wait.PollImmediateUntil(g.Interval, func() (bool, error) {
+		csr, err := g.client.CertificatesV1().CertificateSigningRequests().Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return false, credsgen.WrapError(credsgen.ErrBackendUnavailable, err, fmt.Sprintf("getting CSR '%s'", name))
+		}
+
+		for _, c := range csr.Status.Conditions {
+			if c.Status != corev1.ConditionTrue {
+				continue
+			}
+			switch c.Type {
+			case certv1.CertificateDenied:
+				return false, credsgen.Errorf(credsgen.ErrInvalidRequest, "CSR '%s' was denied: %s", name, c.Message)
+			case certv1.CertificateFailed:
+				return false, credsgen.Errorf(credsgen.ErrBackendUnavailable, "CSR '%s' failed: %s", name, c.Message)
+			}
+		}
+
+		if len(csr.Status.Certificate) == 0 {
+			return false, nil
+		}
+		certificate = csr.Status.Certificate
+		return true, nil
+	}, ctx.Done())
+
+	if err == wait.ErrWaitTimeout {
+		return nil, credsgen.Errorf(credsgen.ErrBackendUnavailable, "timed out waiting for the certificate of CSR '%s'", name)
+	}
+	return certificate, err
+}
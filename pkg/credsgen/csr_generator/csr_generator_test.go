@@ -0,0 +1,207 @@
+package csrgenerator_test
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/pkg/errors"
+	certv1 "k8s.io/api/certificates/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"code.cloudfoundry.org/quarks-utils/pkg/credsgen"
+	csrgenerator "code.cloudfoundry.org/quarks-utils/pkg/credsgen/csr_generator"
+	inmemorygenerator "code.cloudfoundry.org/quarks-utils/pkg/credsgen/in_memory_generator"
+	helper "code.cloudfoundry.org/quarks-utils/testing/testhelper"
+)
+
+var _ = Describe("CSRGenerator", func() {
+	var (
+		client    *fake.Clientset
+		generator *csrgenerator.CSRGenerator
+		clusterCA credsgen.Certificate
+		request   credsgen.CertificateGenerationRequest
+	)
+
+	// sign simulates the signer of the cluster, it issues approved CSRs
+	sign := func(action clienttesting.Action) (bool, runtime.Object, error) {
+		if action.GetSubresource() != "approval" {
+			return false, nil, nil
+		}
+		csr := action.(clienttesting.UpdateAction).GetObject().(*certv1.CertificateSigningRequest)
+
+		block, _ := pem.Decode(csr.Spec.Request)
+		req, err := x509.ParseCertificateRequest(block.Bytes)
+		Expect(err).ToNot(HaveOccurred())
+		block, _ = pem.Decode(clusterCA.Certificate)
+		caCert, err := x509.ParseCertificate(block.Bytes)
+		Expect(err).ToNot(HaveOccurred())
+		block, _ = pem.Decode(clusterCA.PrivateKey)
+		caKey, err := x509.ParseECPrivateKey(block.Bytes)
+		Expect(err).ToNot(HaveOccurred())
+
+		template := &x509.Certificate{
+			SerialNumber: big.NewInt(1),
+			Subject:      req.Subject,
+			DNSNames:     req.DNSNames,
+			NotBefore:    time.Now().Add(-time.Minute),
+			NotAfter:     time.Now().Add(time.Hour),
+			ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		}
+		der, err := x509.CreateCertificate(rand.Reader, template, caCert, req.PublicKey, caKey)
+		Expect(err).ToNot(HaveOccurred())
+		csr.Status.Certificate = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+		return false, nil, nil
+	}
+
+	// generateName simulates the API server, which generates names for CSRs with a generateName
+	generated := 0
+	generateName := func(action clienttesting.Action) (bool, runtime.Object, error) {
+		csr := action.(clienttesting.CreateAction).GetObject().(*certv1.CertificateSigningRequest)
+		if csr.Name == "" && csr.GenerateName != "" {
+			generated++
+			csr.Name = fmt.Sprintf("%s%05d", csr.GenerateName, generated)
+		}
+		return false, nil, nil
+	}
+
+	// approvedCSRs returns the CSRs approved by the generator, which deletes them after use
+	approvedCSRs := func() []*certv1.CertificateSigningRequest {
+		csrs := []*certv1.CertificateSigningRequest{}
+		for _, action := range client.Actions() {
+			if action.GetSubresource() == "approval" {
+				csrs = append(csrs, action.(clienttesting.UpdateAction).GetObject().(*certv1.CertificateSigningRequest))
+			}
+		}
+		return csrs
+	}
+
+	remainingCSRs := func() []string {
+		csrs, err := client.CertificatesV1().CertificateSigningRequests().List(context.Background(), metav1.ListOptions{})
+		Expect(err).ToNot(HaveOccurred())
+		names := []string{}
+		for _, csr := range csrs.Items {
+			names = append(names, csr.Name)
+		}
+		return names
+	}
+
+	BeforeEach(func() {
+		_, log := helper.NewTestLogger()
+		client = fake.NewSimpleClientset()
+		client.PrependReactor("update", "certificatesigningrequests", sign)
+		client.PrependReactor("create", "certificatesigningrequests", generateName)
+
+		generator = csrgenerator.NewCSRGenerator(log, client, certv1.KubeletServingSignerName)
+		generator.Algorithm = "ecdsa"
+		generator.Bits = 256
+		generator.Interval = 10 * time.Millisecond
+		generator.Timeout = time.Second
+
+		caGenerator := inmemorygenerator.NewInMemoryGenerator(log)
+		caGenerator.Algorithm = "ecdsa"
+		caGenerator.Bits = 256
+
+		var err error
+		clusterCA, err = caGenerator.GenerateCertificate("cluster-ca", credsgen.CertificateGenerationRequest{CommonName: "cluster", IsCA: true})
+		Expect(err).ToNot(HaveOccurred())
+
+		request = credsgen.CertificateGenerationRequest{CommonName: "foo.example.com"}
+	})
+
+	Describe("GenerateCertificate", func() {
+		It("submits, approves and waits for a CSR", func() {
+			cert, err := generator.GenerateCertificate("foo", request)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(cert.IsCA).To(BeFalse())
+			Expect(cert.PrivateKey).To(ContainSubstring("BEGIN EC PRIVATE KEY"))
+
+			block, _ := pem.Decode(cert.Certificate)
+			parsed, err := x509.ParseCertificate(block.Bytes)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(parsed.Subject.CommonName).To(Equal("foo.example.com"))
+			Expect(parsed.Issuer.CommonName).To(Equal("cluster"))
+
+			csrs := approvedCSRs()
+			Expect(csrs).To(HaveLen(1))
+			Expect(csrs[0].Name).To(HavePrefix("foo-"))
+			Expect(csrs[0].Spec.SignerName).To(Equal(certv1.KubeletServingSignerName))
+			Expect(csrs[0].Spec.Usages).To(ContainElement(certv1.UsageServerAuth))
+			Expect(csrs[0].Status.Conditions).To(HaveLen(1))
+			Expect(csrs[0].Status.Conditions[0].Type).To(Equal(certv1.CertificateApproved))
+		})
+
+		It("deletes the CSR after the certificate was issued", func() {
+			_, err := generator.GenerateCertificate("foo", request)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(remainingCSRs()).To(BeEmpty())
+		})
+
+		It("uses unique names and keeps CSRs it did not create", func() {
+			_, err := client.CertificatesV1().CertificateSigningRequests().Create(context.Background(), &certv1.CertificateSigningRequest{
+				ObjectMeta: metav1.ObjectMeta{Name: "foo"},
+			}, metav1.CreateOptions{})
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = generator.GenerateCertificate("foo", request)
+			Expect(err).ToNot(HaveOccurred())
+			_, err = generator.GenerateCertificate("foo", request)
+			Expect(err).ToNot(HaveOccurred())
+
+			csrs := approvedCSRs()
+			Expect(csrs).To(HaveLen(2))
+			Expect(csrs[0].Name).To(HavePrefix("foo-"))
+			Expect(csrs[1].Name).To(HavePrefix("foo-"))
+			Expect(csrs[0].Name).ToNot(Equal(csrs[1].Name))
+			Expect(remainingCSRs()).To(ConsistOf("foo"))
+		})
+
+		It("generates CAs in memory", func() {
+			cert, err := generator.GenerateCertificate("ca", credsgen.CertificateGenerationRequest{CommonName: "ca", IsCA: true})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(cert.IsCA).To(BeTrue())
+
+			csrs, err := client.CertificatesV1().CertificateSigningRequests().List(context.Background(), metav1.ListOptions{})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(csrs.Items).To(BeEmpty())
+		})
+
+		It("times out if the CSR is not approved", func() {
+			generator.AutoApprove = false
+			generator.Timeout = 50 * time.Millisecond
+
+			_, err := generator.GenerateCertificate("foo", request)
+			Expect(err).To(MatchError(ContainSubstring("timed out waiting for the certificate of CSR 'foo-")))
+			Expect(errors.Is(err, credsgen.ErrBackendUnavailable)).To(BeTrue())
+			Expect(remainingCSRs()).To(BeEmpty())
+		})
+
+		It("fails if the CSR is denied", func() {
+			generator.AutoApprove = false
+			client.PrependReactor("create", "certificatesigningrequests", func(action clienttesting.Action) (bool, runtime.Object, error) {
+				csr := action.(clienttesting.CreateAction).GetObject().(*certv1.CertificateSigningRequest)
+				csr.Status.Conditions = []certv1.CertificateSigningRequestCondition{{
+					Type:    certv1.CertificateDenied,
+					Status:  corev1.ConditionTrue,
+					Message: "not allowed",
+				}}
+				return false, nil, nil
+			})
+
+			_, err := generator.GenerateCertificate("foo", request)
+			Expect(err).To(MatchError(MatchRegexp("CSR 'foo-[0-9]+' was denied: not allowed")))
+			Expect(errors.Is(err, credsgen.ErrInvalidRequest)).To(BeTrue())
+		})
+	})
+})
@@ -0,0 +1,13 @@
+package csrgenerator_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestCSRGenerator(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "CSRGenerator Suite")
+}
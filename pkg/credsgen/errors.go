@@ -0,0 +1,58 @@
+package credsgen
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	// ErrInvalidRequest is the kind of errors caused by invalid generation requests
+	ErrInvalidRequest = errors.New("invalid generation request")
+	// ErrInvalidCA is the kind of errors caused by a CA, which can't sign the credential
+	ErrInvalidCA = errors.New("invalid CA")
+	// ErrUnsupportedAlgorithm is the kind of errors caused by unsupported key algorithms or sizes
+	ErrUnsupportedAlgorithm = errors.New("unsupported algorithm")
+	// ErrBackendUnavailable is the kind of errors caused by a backend, which
+	// can't generate credentials at the moment, like an entropy source or a
+	// Kubernetes signer. Retrying the generation may succeed.
+	ErrBackendUnavailable = errors.New("credential backend unavailable")
+)
+
+// GenerationError is an error of a generator. Its kind is one of the Err*
+// errors of this package, and errors.Is matches it against the kind.
+type GenerationError struct {
+	Kind error
+	Err  error
+}
+
+func (e *GenerationError) Error() string {
+	if e.Err == nil {
+		return e.Kind.Error()
+	}
+	return fmt.Sprintf("%s: %s", e.Kind, e.Err)
+}
+
+// Unwrap returns the cause of the error
+func (e *GenerationError) Unwrap() error {
+	return e.Err
+}
+
+// Is returns true if the target is the kind of the error
+func (e *GenerationError) Is(target error) bool {
+	return target == e.Kind
+}
+
+// Errorf returns a GenerationError of the kind with a formatted cause
+func Errorf(kind error, format string, args ...interface{}) error {
+	return &GenerationError{Kind: kind, Err: errors.Errorf(format, args...)}
+}
+
+// WrapError returns a GenerationError of the kind, whose cause is err with
+// the message prepended. It returns nil if err is nil.
+func WrapError(kind error, err error, message string) error {
+	if err == nil {
+		return nil
+	}
+	return &GenerationError{Kind: kind, Err: errors.Wrap(err, message)}
+}
@@ -0,0 +1,35 @@
+package credsgen_test
+
+import (
+	"github.com/pkg/errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"code.cloudfoundry.org/quarks-utils/pkg/credsgen"
+)
+
+var _ = Describe("GenerationError", func() {
+	It("matches its kind", func() {
+		err := credsgen.Errorf(credsgen.ErrInvalidCA, "The passed CA is not a CA")
+		Expect(err).To(MatchError("invalid CA: The passed CA is not a CA"))
+		Expect(errors.Is(err, credsgen.ErrInvalidCA)).To(BeTrue())
+		Expect(errors.Is(err, credsgen.ErrInvalidRequest)).To(BeFalse())
+	})
+
+	It("matches its kind and cause when wrapped", func() {
+		cause := errors.New("no entropy")
+		err := errors.Wrap(credsgen.WrapError(credsgen.ErrBackendUnavailable, cause, "reading random bytes"), "generating key")
+		Expect(err).To(MatchError("generating key: credential backend unavailable: reading random bytes: no entropy"))
+		Expect(errors.Is(err, credsgen.ErrBackendUnavailable)).To(BeTrue())
+		Expect(errors.Is(err, cause)).To(BeTrue())
+
+		var generationErr *credsgen.GenerationError
+		Expect(errors.As(err, &generationErr)).To(BeTrue())
+		Expect(generationErr.Kind).To(Equal(credsgen.ErrBackendUnavailable))
+	})
+
+	It("does not wrap nil errors", func() {
+		Expect(credsgen.WrapError(credsgen.ErrInvalidCA, nil, "parsing CA")).To(BeNil())
+	})
+})
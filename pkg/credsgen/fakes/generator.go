@@ -8,6 +8,20 @@ import (
 )
 
 type FakeGenerator struct {
+	GenerateBasicAuthStub        func(string, credsgen.BasicAuthGenerationRequest) (credsgen.BasicAuth, error)
+	generateBasicAuthMutex       sync.RWMutex
+	generateBasicAuthArgsForCall []struct {
+		arg1 string
+		arg2 credsgen.BasicAuthGenerationRequest
+	}
+	generateBasicAuthReturns struct {
+		result1 credsgen.BasicAuth
+		result2 error
+	}
+	generateBasicAuthReturnsOnCall map[int]struct {
+		result1 credsgen.BasicAuth
+		result2 error
+	}
 	GenerateCertificateStub        func(string, credsgen.CertificateGenerationRequest) (credsgen.Certificate, error)
 	generateCertificateMutex       sync.RWMutex
 	generateCertificateArgsForCall []struct {
@@ -37,6 +51,34 @@ type FakeGenerator struct {
 		result2 []byte
 		result3 error
 	}
+	GenerateDHParamsStub        func(string, credsgen.DHParamsGenerationRequest) (credsgen.DHParams, error)
+	generateDHParamsMutex       sync.RWMutex
+	generateDHParamsArgsForCall []struct {
+		arg1 string
+		arg2 credsgen.DHParamsGenerationRequest
+	}
+	generateDHParamsReturns struct {
+		result1 credsgen.DHParams
+		result2 error
+	}
+	generateDHParamsReturnsOnCall map[int]struct {
+		result1 credsgen.DHParams
+		result2 error
+	}
+	GenerateHtpasswdStub        func(string, credsgen.HtpasswdGenerationRequest) (credsgen.Htpasswd, error)
+	generateHtpasswdMutex       sync.RWMutex
+	generateHtpasswdArgsForCall []struct {
+		arg1 string
+		arg2 credsgen.HtpasswdGenerationRequest
+	}
+	generateHtpasswdReturns struct {
+		result1 credsgen.Htpasswd
+		result2 error
+	}
+	generateHtpasswdReturnsOnCall map[int]struct {
+		result1 credsgen.Htpasswd
+		result2 error
+	}
 	GeneratePasswordStub        func(string, credsgen.PasswordGenerationRequest) string
 	generatePasswordMutex       sync.RWMutex
 	generatePasswordArgsForCall []struct {
@@ -79,6 +121,71 @@ type FakeGenerator struct {
 	invocationsMutex sync.RWMutex
 }
 
+func (fake *FakeGenerator) GenerateBasicAuth(arg1 string, arg2 credsgen.BasicAuthGenerationRequest) (credsgen.BasicAuth, error) {
+	fake.generateBasicAuthMutex.Lock()
+	ret, specificReturn := fake.generateBasicAuthReturnsOnCall[len(fake.generateBasicAuthArgsForCall)]
+	fake.generateBasicAuthArgsForCall = append(fake.generateBasicAuthArgsForCall, struct {
+		arg1 string
+		arg2 credsgen.BasicAuthGenerationRequest
+	}{arg1, arg2})
+	stub := fake.GenerateBasicAuthStub
+	fakeReturns := fake.generateBasicAuthReturns
+	fake.recordInvocation("GenerateBasicAuth", []interface{}{arg1, arg2})
+	fake.generateBasicAuthMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeGenerator) GenerateBasicAuthCallCount() int {
+	fake.generateBasicAuthMutex.RLock()
+	defer fake.generateBasicAuthMutex.RUnlock()
+	return len(fake.generateBasicAuthArgsForCall)
+}
+
+func (fake *FakeGenerator) GenerateBasicAuthCalls(stub func(string, credsgen.BasicAuthGenerationRequest) (credsgen.BasicAuth, error)) {
+	fake.generateBasicAuthMutex.Lock()
+	defer fake.generateBasicAuthMutex.Unlock()
+	fake.GenerateBasicAuthStub = stub
+}
+
+func (fake *FakeGenerator) GenerateBasicAuthArgsForCall(i int) (string, credsgen.BasicAuthGenerationRequest) {
+	fake.generateBasicAuthMutex.RLock()
+	defer fake.generateBasicAuthMutex.RUnlock()
+	argsForCall := fake.generateBasicAuthArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeGenerator) GenerateBasicAuthReturns(result1 credsgen.BasicAuth, result2 error) {
+	fake.generateBasicAuthMutex.Lock()
+	defer fake.generateBasicAuthMutex.Unlock()
+	fake.GenerateBasicAuthStub = nil
+	fake.generateBasicAuthReturns = struct {
+		result1 credsgen.BasicAuth
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeGenerator) GenerateBasicAuthReturnsOnCall(i int, result1 credsgen.BasicAuth, result2 error) {
+	fake.generateBasicAuthMutex.Lock()
+	defer fake.generateBasicAuthMutex.Unlock()
+	fake.GenerateBasicAuthStub = nil
+	if fake.generateBasicAuthReturnsOnCall == nil {
+		fake.generateBasicAuthReturnsOnCall = make(map[int]struct {
+			result1 credsgen.BasicAuth
+			result2 error
+		})
+	}
+	fake.generateBasicAuthReturnsOnCall[i] = struct {
+		result1 credsgen.BasicAuth
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeGenerator) GenerateCertificate(arg1 string, arg2 credsgen.CertificateGenerationRequest) (credsgen.Certificate, error) {
 	fake.generateCertificateMutex.Lock()
 	ret, specificReturn := fake.generateCertificateReturnsOnCall[len(fake.generateCertificateArgsForCall)]
@@ -86,15 +193,16 @@ func (fake *FakeGenerator) GenerateCertificate(arg1 string, arg2 credsgen.Certif
 		arg1 string
 		arg2 credsgen.CertificateGenerationRequest
 	}{arg1, arg2})
+	stub := fake.GenerateCertificateStub
+	fakeReturns := fake.generateCertificateReturns
 	fake.recordInvocation("GenerateCertificate", []interface{}{arg1, arg2})
 	fake.generateCertificateMutex.Unlock()
-	if fake.GenerateCertificateStub != nil {
-		return fake.GenerateCertificateStub(arg1, arg2)
+	if stub != nil {
+		return stub(arg1, arg2)
 	}
 	if specificReturn {
 		return ret.result1, ret.result2
 	}
-	fakeReturns := fake.generateCertificateReturns
 	return fakeReturns.result1, fakeReturns.result2
 }
 
@@ -149,15 +257,16 @@ func (fake *FakeGenerator) GenerateCertificateSigningRequest(arg1 credsgen.Certi
 	fake.generateCertificateSigningRequestArgsForCall = append(fake.generateCertificateSigningRequestArgsForCall, struct {
 		arg1 credsgen.CertificateGenerationRequest
 	}{arg1})
+	stub := fake.GenerateCertificateSigningRequestStub
+	fakeReturns := fake.generateCertificateSigningRequestReturns
 	fake.recordInvocation("GenerateCertificateSigningRequest", []interface{}{arg1})
 	fake.generateCertificateSigningRequestMutex.Unlock()
-	if fake.GenerateCertificateSigningRequestStub != nil {
-		return fake.GenerateCertificateSigningRequestStub(arg1)
+	if stub != nil {
+		return stub(arg1)
 	}
 	if specificReturn {
 		return ret.result1, ret.result2, ret.result3
 	}
-	fakeReturns := fake.generateCertificateSigningRequestReturns
 	return fakeReturns.result1, fakeReturns.result2, fakeReturns.result3
 }
 
@@ -209,6 +318,136 @@ func (fake *FakeGenerator) GenerateCertificateSigningRequestReturnsOnCall(i int,
 	}{result1, result2, result3}
 }
 
+func (fake *FakeGenerator) GenerateDHParams(arg1 string, arg2 credsgen.DHParamsGenerationRequest) (credsgen.DHParams, error) {
+	fake.generateDHParamsMutex.Lock()
+	ret, specificReturn := fake.generateDHParamsReturnsOnCall[len(fake.generateDHParamsArgsForCall)]
+	fake.generateDHParamsArgsForCall = append(fake.generateDHParamsArgsForCall, struct {
+		arg1 string
+		arg2 credsgen.DHParamsGenerationRequest
+	}{arg1, arg2})
+	stub := fake.GenerateDHParamsStub
+	fakeReturns := fake.generateDHParamsReturns
+	fake.recordInvocation("GenerateDHParams", []interface{}{arg1, arg2})
+	fake.generateDHParamsMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeGenerator) GenerateDHParamsCallCount() int {
+	fake.generateDHParamsMutex.RLock()
+	defer fake.generateDHParamsMutex.RUnlock()
+	return len(fake.generateDHParamsArgsForCall)
+}
+
+func (fake *FakeGenerator) GenerateDHParamsCalls(stub func(string, credsgen.DHParamsGenerationRequest) (credsgen.DHParams, error)) {
+	fake.generateDHParamsMutex.Lock()
+	defer fake.generateDHParamsMutex.Unlock()
+	fake.GenerateDHParamsStub = stub
+}
+
+func (fake *FakeGenerator) GenerateDHParamsArgsForCall(i int) (string, credsgen.DHParamsGenerationRequest) {
+	fake.generateDHParamsMutex.RLock()
+	defer fake.generateDHParamsMutex.RUnlock()
+	argsForCall := fake.generateDHParamsArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeGenerator) GenerateDHParamsReturns(result1 credsgen.DHParams, result2 error) {
+	fake.generateDHParamsMutex.Lock()
+	defer fake.generateDHParamsMutex.Unlock()
+	fake.GenerateDHParamsStub = nil
+	fake.generateDHParamsReturns = struct {
+		result1 credsgen.DHParams
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeGenerator) GenerateDHParamsReturnsOnCall(i int, result1 credsgen.DHParams, result2 error) {
+	fake.generateDHParamsMutex.Lock()
+	defer fake.generateDHParamsMutex.Unlock()
+	fake.GenerateDHParamsStub = nil
+	if fake.generateDHParamsReturnsOnCall == nil {
+		fake.generateDHParamsReturnsOnCall = make(map[int]struct {
+			result1 credsgen.DHParams
+			result2 error
+		})
+	}
+	fake.generateDHParamsReturnsOnCall[i] = struct {
+		result1 credsgen.DHParams
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeGenerator) GenerateHtpasswd(arg1 string, arg2 credsgen.HtpasswdGenerationRequest) (credsgen.Htpasswd, error) {
+	fake.generateHtpasswdMutex.Lock()
+	ret, specificReturn := fake.generateHtpasswdReturnsOnCall[len(fake.generateHtpasswdArgsForCall)]
+	fake.generateHtpasswdArgsForCall = append(fake.generateHtpasswdArgsForCall, struct {
+		arg1 string
+		arg2 credsgen.HtpasswdGenerationRequest
+	}{arg1, arg2})
+	stub := fake.GenerateHtpasswdStub
+	fakeReturns := fake.generateHtpasswdReturns
+	fake.recordInvocation("GenerateHtpasswd", []interface{}{arg1, arg2})
+	fake.generateHtpasswdMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeGenerator) GenerateHtpasswdCallCount() int {
+	fake.generateHtpasswdMutex.RLock()
+	defer fake.generateHtpasswdMutex.RUnlock()
+	return len(fake.generateHtpasswdArgsForCall)
+}
+
+func (fake *FakeGenerator) GenerateHtpasswdCalls(stub func(string, credsgen.HtpasswdGenerationRequest) (credsgen.Htpasswd, error)) {
+	fake.generateHtpasswdMutex.Lock()
+	defer fake.generateHtpasswdMutex.Unlock()
+	fake.GenerateHtpasswdStub = stub
+}
+
+func (fake *FakeGenerator) GenerateHtpasswdArgsForCall(i int) (string, credsgen.HtpasswdGenerationRequest) {
+	fake.generateHtpasswdMutex.RLock()
+	defer fake.generateHtpasswdMutex.RUnlock()
+	argsForCall := fake.generateHtpasswdArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeGenerator) GenerateHtpasswdReturns(result1 credsgen.Htpasswd, result2 error) {
+	fake.generateHtpasswdMutex.Lock()
+	defer fake.generateHtpasswdMutex.Unlock()
+	fake.GenerateHtpasswdStub = nil
+	fake.generateHtpasswdReturns = struct {
+		result1 credsgen.Htpasswd
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeGenerator) GenerateHtpasswdReturnsOnCall(i int, result1 credsgen.Htpasswd, result2 error) {
+	fake.generateHtpasswdMutex.Lock()
+	defer fake.generateHtpasswdMutex.Unlock()
+	fake.GenerateHtpasswdStub = nil
+	if fake.generateHtpasswdReturnsOnCall == nil {
+		fake.generateHtpasswdReturnsOnCall = make(map[int]struct {
+			result1 credsgen.Htpasswd
+			result2 error
+		})
+	}
+	fake.generateHtpasswdReturnsOnCall[i] = struct {
+		result1 credsgen.Htpasswd
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeGenerator) GeneratePassword(arg1 string, arg2 credsgen.PasswordGenerationRequest) string {
 	fake.generatePasswordMutex.Lock()
 	ret, specificReturn := fake.generatePasswordReturnsOnCall[len(fake.generatePasswordArgsForCall)]
@@ -216,15 +455,16 @@ func (fake *FakeGenerator) GeneratePassword(arg1 string, arg2 credsgen.PasswordG
 		arg1 string
 		arg2 credsgen.PasswordGenerationRequest
 	}{arg1, arg2})
+	stub := fake.GeneratePasswordStub
+	fakeReturns := fake.generatePasswordReturns
 	fake.recordInvocation("GeneratePassword", []interface{}{arg1, arg2})
 	fake.generatePasswordMutex.Unlock()
-	if fake.GeneratePasswordStub != nil {
-		return fake.GeneratePasswordStub(arg1, arg2)
+	if stub != nil {
+		return stub(arg1, arg2)
 	}
 	if specificReturn {
 		return ret.result1
 	}
-	fakeReturns := fake.generatePasswordReturns
 	return fakeReturns.result1
 }
 
@@ -276,15 +516,16 @@ func (fake *FakeGenerator) GenerateRSAKey(arg1 string) (credsgen.RSAKey, error)
 	fake.generateRSAKeyArgsForCall = append(fake.generateRSAKeyArgsForCall, struct {
 		arg1 string
 	}{arg1})
+	stub := fake.GenerateRSAKeyStub
+	fakeReturns := fake.generateRSAKeyReturns
 	fake.recordInvocation("GenerateRSAKey", []interface{}{arg1})
 	fake.generateRSAKeyMutex.Unlock()
-	if fake.GenerateRSAKeyStub != nil {
-		return fake.GenerateRSAKeyStub(arg1)
+	if stub != nil {
+		return stub(arg1)
 	}
 	if specificReturn {
 		return ret.result1, ret.result2
 	}
-	fakeReturns := fake.generateRSAKeyReturns
 	return fakeReturns.result1, fakeReturns.result2
 }
 
@@ -339,15 +580,16 @@ func (fake *FakeGenerator) GenerateSSHKey(arg1 string) (credsgen.SSHKey, error)
 	fake.generateSSHKeyArgsForCall = append(fake.generateSSHKeyArgsForCall, struct {
 		arg1 string
 	}{arg1})
+	stub := fake.GenerateSSHKeyStub
+	fakeReturns := fake.generateSSHKeyReturns
 	fake.recordInvocation("GenerateSSHKey", []interface{}{arg1})
 	fake.generateSSHKeyMutex.Unlock()
-	if fake.GenerateSSHKeyStub != nil {
-		return fake.GenerateSSHKeyStub(arg1)
+	if stub != nil {
+		return stub(arg1)
 	}
 	if specificReturn {
 		return ret.result1, ret.result2
 	}
-	fakeReturns := fake.generateSSHKeyReturns
 	return fakeReturns.result1, fakeReturns.result2
 }
 
@@ -399,10 +641,16 @@ func (fake *FakeGenerator) GenerateSSHKeyReturnsOnCall(i int, result1 credsgen.S
 func (fake *FakeGenerator) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
+	fake.generateBasicAuthMutex.RLock()
+	defer fake.generateBasicAuthMutex.RUnlock()
 	fake.generateCertificateMutex.RLock()
 	defer fake.generateCertificateMutex.RUnlock()
 	fake.generateCertificateSigningRequestMutex.RLock()
 	defer fake.generateCertificateSigningRequestMutex.RUnlock()
+	fake.generateDHParamsMutex.RLock()
+	defer fake.generateDHParamsMutex.RUnlock()
+	fake.generateHtpasswdMutex.RLock()
+	defer fake.generateHtpasswdMutex.RUnlock()
 	fake.generatePasswordMutex.RLock()
 	defer fake.generatePasswordMutex.RUnlock()
 	fake.generateRSAKeyMutex.RLock()
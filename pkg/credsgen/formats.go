@@ -0,0 +1,267 @@
+package credsgen
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pavel-v-chernykh/keystore-go/v4"
+	"github.com/pkg/errors"
+	"software.sslmate.com/src/go-pkcs12"
+)
+
+const (
+	// DefaultKeystoreAlias is the alias of the private key entry in JKS keystores
+	DefaultKeystoreAlias = "key"
+
+	// keystorePasswordBytes is the number of random bytes of generated
+	// keystore passwords, which are hex encoded to DefaultPasswordLength characters
+	keystorePasswordBytes = DefaultPasswordLength / 2
+)
+
+// Keystore is a PKCS#12 or JKS keystore and the password protecting it and its private keys
+type Keystore struct {
+	Data     []byte
+	Password string
+}
+
+// PKCS8PrivateKey converts a PEM encoded PKCS#1 RSA, SEC 1 EC or PKCS#8 private key to PKCS#8 PEM
+func PKCS8PrivateKey(privateKeyPEM []byte) ([]byte, error) {
+	key, err := parsePrivateKey(privateKeyPEM)
+	if err != nil {
+		return nil, err
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, errors.Wrap(err, "marshaling PKCS#8 private key")
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+}
+
+// PKCS8 returns the private key of the certificate as PKCS#8 PEM
+func (c Certificate) PKCS8() ([]byte, error) {
+	return PKCS8PrivateKey(c.PrivateKey)
+}
+
+// PKCS8 returns the private key as PKCS#8 PEM
+func (k RSAKey) PKCS8() ([]byte, error) {
+	return PKCS8PrivateKey(k.PrivateKey)
+}
+
+// PKCS12 returns a PKCS#12 keystore with the private key, the certificate,
+// its chain and the certificates of the CAs. A password is generated if it's empty.
+func (c Certificate) PKCS12(password string, cas ...Certificate) (Keystore, error) {
+	password, err := keystorePassword(password)
+	if err != nil {
+		return Keystore{}, err
+	}
+
+	key, err := parsePrivateKey(c.PrivateKey)
+	if err != nil {
+		return Keystore{}, err
+	}
+
+	chain, err := parseCertificates(c.FullChain())
+	if err != nil {
+		return Keystore{}, err
+	}
+
+	caCerts, err := parseCACertificates(cas)
+	if err != nil {
+		return Keystore{}, err
+	}
+
+	data, err := pkcs12.Encode(rand.Reader, key, chain[0], append(chain[1:], caCerts...), password)
+	if err != nil {
+		return Keystore{}, errors.Wrap(err, "encoding PKCS#12 keystore")
+	}
+	return Keystore{Data: data, Password: password}, nil
+}
+
+// PKCS12TrustStore returns a PKCS#12 truststore with the certificates. A
+// password is generated if it's empty.
+func PKCS12TrustStore(password string, certs ...Certificate) (Keystore, error) {
+	password, err := keystorePassword(password)
+	if err != nil {
+		return Keystore{}, err
+	}
+
+	caCerts, err := parseCACertificates(certs)
+	if err != nil {
+		return Keystore{}, err
+	}
+
+	data, err := pkcs12.EncodeTrustStore(rand.Reader, caCerts, password)
+	if err != nil {
+		return Keystore{}, errors.Wrap(err, "encoding PKCS#12 truststore")
+	}
+	return Keystore{Data: data, Password: password}, nil
+}
+
+// JKS returns a JKS keystore with the private key, the certificate, its
+// chain and the certificates of the CAs as chain, stored under DefaultKeystoreAlias. The
+// private key is protected with the keystore password, which is generated
+// if it's empty.
+func (c Certificate) JKS(password string, cas ...Certificate) (Keystore, error) {
+	password, err := keystorePassword(password)
+	if err != nil {
+		return Keystore{}, err
+	}
+
+	key, err := parsePrivateKey(c.PrivateKey)
+	if err != nil {
+		return Keystore{}, err
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return Keystore{}, errors.Wrap(err, "marshaling PKCS#8 private key")
+	}
+
+	chain, err := parseCertificates(c.FullChain())
+	if err != nil {
+		return Keystore{}, err
+	}
+	caCerts, err := parseCACertificates(cas)
+	if err != nil {
+		return Keystore{}, err
+	}
+
+	entry := keystore.PrivateKeyEntry{
+		CreationTime: time.Now(),
+		PrivateKey:   der,
+	}
+	for _, cert := range append(chain, caCerts...) {
+		entry.CertificateChain = append(entry.CertificateChain, keystore.Certificate{Type: "X509", Content: cert.Raw})
+	}
+
+	ks := keystore.New()
+	if err := ks.SetPrivateKeyEntry(DefaultKeystoreAlias, entry, []byte(password)); err != nil {
+		return Keystore{}, errors.Wrap(err, "adding private key to JKS keystore")
+	}
+	return storeJKS(ks, password)
+}
+
+// JKSTrustStore returns a JKS truststore with the certificates. The aliases
+// are the lower case common names of the certificates. A password is
+// generated if it's empty.
+func JKSTrustStore(password string, certs ...Certificate) (Keystore, error) {
+	password, err := keystorePassword(password)
+	if err != nil {
+		return Keystore{}, err
+	}
+
+	caCerts, err := parseCACertificates(certs)
+	if err != nil {
+		return Keystore{}, err
+	}
+
+	ks := keystore.New()
+	for i, cert := range caCerts {
+		entry := keystore.TrustedCertificateEntry{
+			CreationTime: time.Now(),
+			Certificate:  keystore.Certificate{Type: "X509", Content: cert.Raw},
+		}
+		if err := ks.SetTrustedCertificateEntry(trustStoreAlias(ks, cert, i), entry); err != nil {
+			return Keystore{}, errors.Wrap(err, "adding certificate to JKS truststore")
+		}
+	}
+	return storeJKS(ks, password)
+}
+
+func storeJKS(ks keystore.KeyStore, password string) (Keystore, error) {
+	buf := &bytes.Buffer{}
+	if err := ks.Store(buf, []byte(password)); err != nil {
+		return Keystore{}, errors.Wrap(err, "encoding JKS keystore")
+	}
+	return Keystore{Data: buf.Bytes(), Password: password}, nil
+}
+
+func trustStoreAlias(ks keystore.KeyStore, cert *x509.Certificate, i int) string {
+	alias := strings.ToLower(cert.Subject.CommonName)
+	if alias == "" {
+		alias = "certificate"
+	}
+
+	for _, existing := range ks.Aliases() {
+		if existing == alias {
+			return fmt.Sprintf("%s-%d", alias, i)
+		}
+	}
+	return alias
+}
+
+func keystorePassword(password string) (string, error) {
+	if password != "" {
+		return password, nil
+	}
+
+	b := make([]byte, keystorePasswordBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", errors.Wrap(err, "generating keystore password")
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func parsePrivateKey(privateKeyPEM []byte) (interface{}, error) {
+	block, _ := pem.Decode(privateKeyPEM)
+	if block == nil {
+		return nil, errors.New("decoding private key PEM")
+	}
+
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		key, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+		return key, errors.Wrap(err, "parsing PKCS#1 private key")
+	case "EC PRIVATE KEY":
+		key, err := x509.ParseECPrivateKey(block.Bytes)
+		return key, errors.Wrap(err, "parsing EC private key")
+	case "PRIVATE KEY":
+		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		return key, errors.Wrap(err, "parsing PKCS#8 private key")
+	}
+	return nil, errors.Errorf("unsupported private key type '%s'", block.Type)
+}
+
+// parseCertificates parses all certificates of the PEM data, the leaf certificate comes first
+func parseCertificates(certPEM []byte) ([]*x509.Certificate, error) {
+	certs := []*x509.Certificate{}
+	for {
+		var block *pem.Block
+		block, certPEM = pem.Decode(certPEM)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, errors.Wrap(err, "parsing certificate")
+		}
+		certs = append(certs, cert)
+	}
+
+	if len(certs) == 0 {
+		return nil, errors.New("no certificate found in PEM data")
+	}
+	return certs, nil
+}
+
+func parseCACertificates(cas []Certificate) ([]*x509.Certificate, error) {
+	certs := []*x509.Certificate{}
+	for _, ca := range cas {
+		parsed, err := parseCertificates(ca.Certificate)
+		if err != nil {
+			return nil, err
+		}
+		certs = append(certs, parsed...)
+	}
+	return certs, nil
+}
@@ -5,6 +5,12 @@ const (
 	// DefaultPasswordLength represents the default length of a generated password
 	// (number of characters)
 	DefaultPasswordLength = 64
+
+	// DefaultHtpasswdCost is the default bcrypt cost of htpasswd entries
+	DefaultHtpasswdCost = 10
+
+	// DefaultDHParamsBits is the default size of the DH parameters' prime in bits
+	DefaultDHParamsBits = 2048
 )
 
 // PasswordGenerationRequest specifies the generation parameters for Passwords
@@ -12,6 +18,28 @@ type PasswordGenerationRequest struct {
 	Length int
 }
 
+// HtpasswdGenerationRequest specifies the generation parameters for htpasswd entries
+type HtpasswdGenerationRequest struct {
+	Username string
+	// Password is hashed into the entry, a password is generated if it's empty
+	Password string
+	// Cost is the bcrypt cost, it defaults to DefaultHtpasswdCost
+	Cost int
+}
+
+// BasicAuthGenerationRequest specifies the generation parameters for basic auth credentials
+type BasicAuthGenerationRequest struct {
+	Username string
+	// PasswordLength is the length of the generated password, it defaults to DefaultPasswordLength
+	PasswordLength int
+}
+
+// DHParamsGenerationRequest specifies the generation parameters for Diffie-Hellman parameters
+type DHParamsGenerationRequest struct {
+	// Bits is the size of the prime, it defaults to DefaultDHParamsBits
+	Bits int
+}
+
 // CertificateGenerationRequest specifies the generation parameters for Certificates
 type CertificateGenerationRequest struct {
 	CommonName       string
@@ -25,6 +53,9 @@ type Certificate struct {
 	IsCA        bool
 	Certificate []byte
 	PrivateKey  []byte
+	// Chain holds the PEM encoded intermediate CA certificates, which issued
+	// the certificate, starting with its issuer. Root CAs are not part of it.
+	Chain []byte
 }
 
 // SSHKey represents an SSH key
@@ -40,6 +71,26 @@ type RSAKey struct {
 	PublicKey  []byte
 }
 
+// Htpasswd represents an htpasswd entry, which holds the bcrypt hash of the password
+type Htpasswd struct {
+	Username string
+	Password string
+	// Entry is the htpasswd line of the user, without a trailing newline
+	Entry string
+}
+
+// BasicAuth represents the credentials of HTTP basic authentication
+type BasicAuth struct {
+	Username string
+	Password string
+}
+
+// DHParams represents Diffie-Hellman parameters
+type DHParams struct {
+	// Parameters are PEM encoded as "DH PARAMETERS", like the ones of openssl dhparam
+	Parameters []byte
+}
+
 // Generator provides an interface for generating credentials like passwords, certificates or SSH and RSA keys
 type Generator interface {
 	GeneratePassword(name string, request PasswordGenerationRequest) string
@@ -47,4 +98,7 @@ type Generator interface {
 	GenerateCertificateSigningRequest(request CertificateGenerationRequest) ([]byte, []byte, error)
 	GenerateSSHKey(name string) (SSHKey, error)
 	GenerateRSAKey(name string) (RSAKey, error)
+	GenerateHtpasswd(name string, request HtpasswdGenerationRequest) (Htpasswd, error)
+	GenerateBasicAuth(name string, request BasicAuthGenerationRequest) (BasicAuth, error)
+	GenerateDHParams(name string, request DHParamsGenerationRequest) (DHParams, error)
 }
@@ -0,0 +1,27 @@
+package inmemorygenerator
+
+import (
+	"strings"
+
+	"code.cloudfoundry.org/quarks-utils/pkg/credsgen"
+)
+
+// GenerateBasicAuth generates basic auth credentials with a random password
+func (g InMemoryGenerator) GenerateBasicAuth(name string, request credsgen.BasicAuthGenerationRequest) (credsgen.BasicAuth, error) {
+	g.log.Debugf("Generating basic auth credentials %s", name)
+
+	// RFC 7617 does not allow colons in user names
+	if request.Username == "" || strings.Contains(request.Username, ":") {
+		return credsgen.BasicAuth{}, credsgen.Errorf(credsgen.ErrInvalidRequest, "invalid basic auth username '%s'", request.Username)
+	}
+
+	length := request.PasswordLength
+	if length == 0 {
+		length = credsgen.DefaultPasswordLength
+	}
+
+	return credsgen.BasicAuth{
+		Username: request.Username,
+		Password: randomString(g.rand(), length),
+	}, nil
+}
@@ -0,0 +1,43 @@
+package inmemorygenerator_test
+
+import (
+	"github.com/pkg/errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"code.cloudfoundry.org/quarks-utils/pkg/credsgen"
+	inmemorygenerator "code.cloudfoundry.org/quarks-utils/pkg/credsgen/in_memory_generator"
+	helper "code.cloudfoundry.org/quarks-utils/testing/testhelper"
+)
+
+var _ = Describe("InMemoryGenerator", func() {
+	var (
+		generator credsgen.Generator
+	)
+
+	BeforeEach(func() {
+		_, log := helper.NewTestLogger()
+		generator = inmemorygenerator.NewInMemoryGenerator(log)
+	})
+
+	Describe("GenerateBasicAuth", func() {
+		It("generates a password with the default length", func() {
+			basicAuth, err := generator.GenerateBasicAuth("foo", credsgen.BasicAuthGenerationRequest{Username: "admin"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(basicAuth.Username).To(Equal("admin"))
+			Expect(basicAuth.Password).To(HaveLen(credsgen.DefaultPasswordLength))
+		})
+
+		It("considers custom lengths", func() {
+			basicAuth, err := generator.GenerateBasicAuth("foo", credsgen.BasicAuthGenerationRequest{Username: "admin", PasswordLength: 10})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(basicAuth.Password).To(HaveLen(10))
+		})
+
+		It("fails for invalid usernames", func() {
+			_, err := generator.GenerateBasicAuth("foo", credsgen.BasicAuthGenerationRequest{Username: "ad:min"})
+			Expect(errors.Is(err, credsgen.ErrInvalidRequest)).To(BeTrue())
+		})
+	})
+})
@@ -1,25 +1,33 @@
 package inmemorygenerator
 
 import (
-	"fmt"
+	"crypto"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"net/mail"
+	"net/url"
 	"time"
 
 	"code.cloudfoundry.org/quarks-utils/pkg/credsgen"
-	"github.com/cloudflare/cfssl/cli/genkey"
-	"github.com/cloudflare/cfssl/config"
-	"github.com/cloudflare/cfssl/csr"
-	"github.com/cloudflare/cfssl/helpers"
-	"github.com/cloudflare/cfssl/initca"
-	cfssllog "github.com/cloudflare/cfssl/log"
-	"github.com/cloudflare/cfssl/signer"
-	"github.com/cloudflare/cfssl/signer/local"
 	"github.com/pkg/errors"
 )
 
-// GenerateCertificate generates a certificate using Cloudflare's TLS toolkit
+const (
+	// intermediateCAExpiry is the validity of intermediate CAs
+	intermediateCAExpiry = 5 * 365 * 24 * time.Hour
+	// backdate is subtracted from the start of the validity, to allow for clock skew
+	backdate = 5 * time.Minute
+	// serialNumberLength is the length of serial numbers in bytes, as in RFC 5280, section 4.1.2.2
+	serialNumberLength = 20
+)
+
+// GenerateCertificate generates a certificate using go's standard crypto library
 func (g InMemoryGenerator) GenerateCertificate(name string, request credsgen.CertificateGenerationRequest) (credsgen.Certificate, error) {
 	g.log.Debugf("Generating certificate %s", name)
-	cfssllog.Level = cfssllog.LevelWarning
 
 	var certificate credsgen.Certificate
 	var err error
@@ -40,33 +48,34 @@ func (g InMemoryGenerator) GenerateCertificate(name string, request credsgen.Cer
 
 // GenerateCertificateSigningRequest Generates a certificate signing request and private key
 func (g InMemoryGenerator) GenerateCertificateSigningRequest(request credsgen.CertificateGenerationRequest) ([]byte, []byte, error) {
-	cfssllog.Level = cfssllog.LevelWarning
-
-	var csReq, privateKey []byte
+	key, err := g.generateKey()
+	if err != nil {
+		return nil, nil, err
+	}
+	privateKey, err := marshalPrivateKey(key)
+	if err != nil {
+		return nil, nil, err
+	}
 
 	// Generate certificate request
-	certReq := &csr.CertificateRequest{KeyRequest: &csr.KeyRequest{A: g.Algorithm, S: g.Bits}}
-
-	certReq.Hosts = append(certReq.Hosts, request.CommonName)
-	certReq.Hosts = append(certReq.Hosts, request.AlternativeNames...)
-	certReq.CN = certReq.Hosts[0]
+	template := &x509.CertificateRequest{
+		Subject:            pkix.Name{CommonName: request.CommonName},
+		SignatureAlgorithm: signatureAlgorithm(key),
+	}
+	hosts := append([]string{request.CommonName}, request.AlternativeNames...)
+	template.DNSNames, template.IPAddresses, template.EmailAddresses, template.URIs = splitHosts(hosts)
 
-	sslValidator := &csr.Generator{Validator: genkey.Validator}
-	csReq, privateKey, err := sslValidator.ProcessRequest(certReq)
+	csReq, err := x509.CreateCertificateRequest(g.signingRand(), template, key)
 	if err != nil {
-		return csReq, privateKey, err
+		return nil, nil, errors.Wrap(err, "creating certificate request")
 	}
-	return csReq, privateKey, nil
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: csReq}), privateKey, nil
 }
 
 // generateCertificate Generate a local-issued certificate and private key
 func (g InMemoryGenerator) generateCertificate(request credsgen.CertificateGenerationRequest) (credsgen.Certificate, error) {
 	if !request.CA.IsCA {
-		return credsgen.Certificate{}, errors.Errorf("The passed CA is not a CA")
-	}
-
-	cert := credsgen.Certificate{
-		IsCA: false,
+		return credsgen.Certificate{}, credsgen.Errorf(credsgen.ErrInvalidCA, "The passed CA is not a CA")
 	}
 
 	// Generate certificate
@@ -74,82 +83,172 @@ func (g InMemoryGenerator) generateCertificate(request credsgen.CertificateGener
 	if err != nil {
 		return credsgen.Certificate{}, err
 	}
+	csr, err := parseCertificateRequest(signingReq)
+	if err != nil {
+		return credsgen.Certificate{}, err
+	}
+
+	template, err := g.template(csr.PublicKey, time.Duration(g.Expiry*24)*time.Hour)
+	if err != nil {
+		return credsgen.Certificate{}, err
+	}
+	template.Subject = csr.Subject
+	template.DNSNames = csr.DNSNames
+	template.IPAddresses = csr.IPAddresses
+	template.EmailAddresses = csr.EmailAddresses
+	template.URIs = csr.URIs
+	template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth}
+
 	// Sign certificate
-	signingProfile := &config.SigningProfile{
-		Usage:        []string{"server auth", "client auth"},
-		Expiry:       time.Duration(g.Expiry*24) * time.Hour,
-		ExpiryString: fmt.Sprintf("%dh", g.Expiry*24),
+	certificate, err := g.signCertificate(template, csr.PublicKey, request.CA)
+	if err != nil {
+		return credsgen.Certificate{}, err
 	}
-	cert.Certificate, err = g.signCertificate(signingReq, signingProfile, request)
+	chain, err := credsgen.IssuerChain(request.CA)
 	if err != nil {
 		return credsgen.Certificate{}, err
 	}
-	cert.PrivateKey = privateKey
 
-	return cert, nil
+	return credsgen.Certificate{
+		IsCA:        false,
+		Certificate: certificate,
+		PrivateKey:  privateKey,
+		Chain:       chain,
+	}, nil
 }
 
 // generateCACertificate Generate self-signed root CA certificate and private key
 func (g InMemoryGenerator) generateCACertificate(request credsgen.CertificateGenerationRequest) (credsgen.Certificate, error) {
-	req := &csr.CertificateRequest{
-		CA:         &csr.CAConfig{Expiry: fmt.Sprintf("%dh", g.Expiry*24)},
-		CN:         request.CommonName,
-		KeyRequest: &csr.KeyRequest{A: g.Algorithm, S: g.Bits},
+	key, err := g.generateKey()
+	if err != nil {
+		return credsgen.Certificate{}, err
 	}
-	ca, csr, privateKey, err := initca.New(req)
+	privateKey, err := marshalPrivateKey(key)
 	if err != nil {
 		return credsgen.Certificate{}, err
 	}
 
-	cert := credsgen.Certificate{
-		IsCA:        true,
-		Certificate: ca,
-		PrivateKey:  privateKey,
+	expiry := time.Duration(g.Expiry*24) * time.Hour
+	if request.CA.IsCA {
+		expiry = intermediateCAExpiry
 	}
+	template, err := g.template(key.Public(), expiry)
+	if err != nil {
+		return credsgen.Certificate{}, err
+	}
+	template.Subject = pkix.Name{CommonName: request.CommonName}
+	template.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageCRLSign
+	template.IsCA = true
+
+	var certificate, chain []byte
 	if request.CA.IsCA {
-		signingProfile := &config.SigningProfile{
-			Usage:        []string{"cert sign", "crl sign"},
-			ExpiryString: "43800h",
-			Expiry:       5 * helpers.OneYear,
-			CAConstraint: config.CAConstraint{
-				IsCA: true,
-			},
-		}
-		cert.Certificate, err = g.signCertificate(csr, signingProfile, request)
-		if err != nil {
-			return credsgen.Certificate{}, err
+		certificate, err = g.signCertificate(template, key.Public(), request.CA)
+		if err == nil {
+			chain, err = credsgen.IssuerChain(request.CA)
 		}
+	} else {
+		certificate, err = g.createCertificate(template, template, key.Public(), key)
+	}
+	if err != nil {
+		return credsgen.Certificate{}, err
 	}
 
-	return cert, nil
+	return credsgen.Certificate{
+		IsCA:        true,
+		Certificate: certificate,
+		PrivateKey:  privateKey,
+		Chain:       chain,
+	}, nil
 }
 
-// Given a signing profile, csr  & request with CA, the certificate is signed by the CA.
-func (g InMemoryGenerator) signCertificate(csr []byte, signingProfile *config.SigningProfile, request credsgen.CertificateGenerationRequest) ([]byte, error) {
+// template returns a certificate template for the public key. The validity
+// starts shortly before the current time of the generator's clock.
+func (g InMemoryGenerator) template(pub crypto.PublicKey, expiry time.Duration) (*x509.Certificate, error) {
+	serialNumber := make([]byte, serialNumberLength)
+	if _, err := io.ReadFull(g.rand(), serialNumber); err != nil {
+		return nil, credsgen.WrapError(credsgen.ErrBackendUnavailable, err, "generating serial number")
+	}
+	// serial numbers must be positive
+	serialNumber[0] &= 0x7F
 
-	policy := &config.Signing{
-		Profiles: map[string]*config.SigningProfile{},
-		Default:  signingProfile,
+	ski, err := subjectKeyID(pub)
+	if err != nil {
+		return nil, err
 	}
 
+	notBefore := g.now().Round(time.Minute).Add(-backdate).UTC()
+	return &x509.Certificate{
+		SerialNumber:          new(big.Int).SetBytes(serialNumber),
+		NotBefore:             notBefore,
+		NotAfter:              notBefore.Add(expiry),
+		BasicConstraintsValid: true,
+		SubjectKeyId:          ski,
+	}, nil
+}
+
+// Given a certificate template and the CA, the certificate is signed by the CA.
+func (g InMemoryGenerator) signCertificate(template *x509.Certificate, pub crypto.PublicKey, ca credsgen.Certificate) ([]byte, error) {
 	// Parse parent CA
-	parentCACert, err := helpers.ParseCertificatePEM([]byte(request.CA.Certificate))
+	parentCACert, err := parseCertificate(ca.Certificate)
 	if err != nil {
-		return []byte{}, errors.Wrap(err, "Parsing CA PEM failed.")
+		return []byte{}, credsgen.WrapError(credsgen.ErrInvalidCA, err, "Parsing CA PEM failed.")
 	}
-	parentCAKey, err := helpers.ParsePrivateKeyPEM([]byte(request.CA.PrivateKey))
+	parentCAKey, err := parsePrivateKey(ca.PrivateKey)
 	if err != nil {
-		return []byte{}, errors.Wrap(err, "Parsing CA private key failed.")
+		return []byte{}, credsgen.WrapError(credsgen.ErrInvalidCA, err, "Parsing CA private key failed.")
 	}
 
-	s, err := local.NewSigner(parentCAKey, parentCACert, signer.DefaultSigAlgo(parentCAKey), policy)
+	certificate, err := g.createCertificate(template, parentCACert, pub, parentCAKey)
 	if err != nil {
-		return []byte{}, errors.Wrap(err, "Creating signer failed.")
+		return []byte{}, errors.Wrap(err, "Signing certificate failed.")
 	}
-	certificate, err := s.Sign(signer.SignRequest{Request: string(csr)})
+	return certificate, nil
+}
+
+func (g InMemoryGenerator) createCertificate(template *x509.Certificate, parent *x509.Certificate, pub crypto.PublicKey, signer crypto.Signer) ([]byte, error) {
+	template.SignatureAlgorithm = signatureAlgorithm(signer)
+	der, err := x509.CreateCertificate(g.signingRand(), template, parent, pub, signer)
 	if err != nil {
-		return []byte{}, errors.Wrap(err, "Signing certificate failed.")
+		return nil, errors.Wrap(err, "creating certificate")
 	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), nil
+}
 
-	return certificate, nil
+// splitHosts sorts the hosts into DNS names, IP addresses, email addresses and URIs
+func splitHosts(hosts []string) (dnsNames []string, ips []net.IP, emails []string, uris []*url.URL) {
+	for _, host := range hosts {
+		if host == "" {
+			continue
+		}
+		if ip := net.ParseIP(host); ip != nil {
+			ips = append(ips, ip)
+		} else if email, err := mail.ParseAddress(host); err == nil && email != nil {
+			emails = append(emails, email.Address)
+		} else if uri, err := url.ParseRequestURI(host); err == nil && uri.Scheme != "" && uri.Host != "" {
+			uris = append(uris, uri)
+		} else {
+			dnsNames = append(dnsNames, host)
+		}
+	}
+	return
+}
+
+func parseCertificate(certPEM []byte) (*x509.Certificate, error) {
+	block, _ := pem.Decode(certPEM)
+	if block == nil {
+		return nil, errors.New("decoding certificate PEM")
+	}
+	return x509.ParseCertificate(block.Bytes)
+}
+
+func parseCertificateRequest(csrPEM []byte) (*x509.CertificateRequest, error) {
+	block, _ := pem.Decode(csrPEM)
+	if block == nil {
+		return nil, errors.New("decoding certificate request PEM")
+	}
+	csr, err := x509.ParseCertificateRequest(block.Bytes)
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing certificate request")
+	}
+	return csr, nil
 }
@@ -13,7 +13,6 @@ import (
 	inmemorygenerator "code.cloudfoundry.org/quarks-utils/pkg/credsgen/in_memory_generator"
 	helper "code.cloudfoundry.org/quarks-utils/testing/testhelper"
 
-	cfssllog "github.com/cloudflare/cfssl/log"
 	"github.com/pkg/errors"
 )
 
@@ -23,8 +22,6 @@ var _ = Describe("InMemoryGenerator", func() {
 	)
 
 	BeforeEach(func() {
-		cfssllog.Level = cfssllog.LevelFatal
-
 		_, log := helper.NewTestLogger()
 		generator = inmemorygenerator.NewInMemoryGenerator(log)
 		// speed up tests with a fast algo
@@ -60,6 +57,7 @@ var _ = Describe("InMemoryGenerator", func() {
 				_, err := generator.GenerateCertificate("foo", request)
 				Expect(err).To(HaveOccurred())
 				Expect(err.Error()).To(ContainSubstring("not a CA"))
+				Expect(errors.Is(err, credsgen.ErrInvalidCA)).To(BeTrue())
 			})
 
 			It("considers the common name", func() {
@@ -75,6 +73,22 @@ var _ = Describe("InMemoryGenerator", func() {
 				Expect(parsedCert.Issuer.CommonName).To(Equal(caCommonName))
 			})
 
+			It("is signed by the CA", func() {
+				request.CommonName = "foo.com"
+				cert, err := generator.GenerateCertificate("foo", request)
+				Expect(err).ToNot(HaveOccurred())
+
+				parsedCert, err := parseCert(cert.Certificate)
+				Expect(err).ToNot(HaveOccurred())
+				parsedCA, err := parseCert(ca.Certificate)
+				Expect(err).ToNot(HaveOccurred())
+
+				roots := x509.NewCertPool()
+				roots.AddCert(parsedCA)
+				_, err = parsedCert.Verify(x509.VerifyOptions{DNSName: "foo.com", Roots: roots})
+				Expect(err).ToNot(HaveOccurred())
+			})
+
 			It("considers the alternative names", func() {
 				request.CommonName = "foo.com"
 				request.AlternativeNames = []string{"bar.com", "baz.com"}
@@ -109,6 +123,70 @@ var _ = Describe("InMemoryGenerator", func() {
 					Expect(len(cert.PrivateKey)).To(Equal(227))
 				})
 			})
+
+			It("uses the usages and validity of the leaf profile", func() {
+				request.CommonName = "foo.com"
+				cert, err := generator.GenerateCertificate("foo", request)
+				Expect(err).ToNot(HaveOccurred())
+
+				parsedCert, err := parseCert(cert.Certificate)
+				Expect(err).ToNot(HaveOccurred())
+				parsedCA, err := parseCert(ca.Certificate)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(parsedCert.KeyUsage).To(BeZero())
+				Expect(parsedCert.ExtKeyUsage).To(ConsistOf(x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth))
+				Expect(parsedCert.SignatureAlgorithm).To(Equal(x509.ECDSAWithSHA256))
+				Expect(parsedCert.SubjectKeyId).ToNot(BeEmpty())
+				Expect(parsedCert.AuthorityKeyId).To(Equal(parsedCA.SubjectKeyId))
+				Expect(parsedCert.NotAfter.Sub(parsedCert.NotBefore)).To(Equal(365 * 24 * time.Hour))
+				Expect(parsedCert.NotBefore.Before(time.Now().Add(-4 * time.Minute))).To(BeTrue())
+			})
+
+			It("sorts IP and email alternative names", func() {
+				request.CommonName = "foo.com"
+				request.AlternativeNames = []string{"10.0.0.1", "admin@foo.com"}
+				cert, err := generator.GenerateCertificate("foo", request)
+				Expect(err).ToNot(HaveOccurred())
+
+				parsedCert, err := parseCert(cert.Certificate)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(parsedCert.DNSNames).To(Equal([]string{"foo.com"}))
+				Expect(parsedCert.IPAddresses).To(HaveLen(1))
+				Expect(parsedCert.IPAddresses[0].String()).To(Equal("10.0.0.1"))
+				Expect(parsedCert.EmailAddresses).To(Equal([]string{"admin@foo.com"}))
+			})
+
+			Context("with ed25519 keys", func() {
+				It("generates the CA and the certificate", func() {
+					g := generator.(*inmemorygenerator.InMemoryGenerator)
+					g.Algorithm = "ed25519"
+
+					ca, err := g.GenerateCertificate("testca", credsgen.CertificateGenerationRequest{CommonName: caCommonName, IsCA: true})
+					Expect(err).ToNot(HaveOccurred())
+					cert, err := g.GenerateCertificate("foo", credsgen.CertificateGenerationRequest{CommonName: "foo.com", CA: ca})
+					Expect(err).ToNot(HaveOccurred())
+
+					key, _ := pem.Decode(cert.PrivateKey)
+					Expect(key.Type).To(Equal("PRIVATE KEY"))
+
+					parsedCert, err := parseCert(cert.Certificate)
+					Expect(err).ToNot(HaveOccurred())
+					parsedCA, err := parseCert(ca.Certificate)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(parsedCert.PublicKeyAlgorithm).To(Equal(x509.Ed25519))
+					Expect(parsedCert.CheckSignatureFrom(parsedCA)).To(Succeed())
+				})
+			})
+
+			It("fails for unknown algorithms", func() {
+				g := generator.(*inmemorygenerator.InMemoryGenerator)
+				g.Algorithm = "dsa"
+
+				_, err := g.GenerateCertificate("foo", request)
+				Expect(err).To(MatchError(ContainSubstring("invalid algorithm 'dsa'")))
+				Expect(errors.Is(err, credsgen.ErrUnsupportedAlgorithm)).To(BeTrue())
+			})
 		})
 
 		Context("when generating a CA", func() {
@@ -140,6 +218,7 @@ var _ = Describe("InMemoryGenerator", func() {
 					Expect(parsedCert.IsCA).To(BeTrue())
 					Expect(cert.PrivateKey).ToNot(BeEmpty())
 					Expect(parsedCert.Subject.CommonName).To(Equal(request.CommonName))
+					Expect(cert.Chain).To(BeEmpty())
 				})
 
 				It("creates an intermediate CA", func() {
@@ -156,6 +235,26 @@ var _ = Describe("InMemoryGenerator", func() {
 					Expect(parsedCert.IsCA).To(BeTrue())
 					Expect(cert.PrivateKey).ToNot(BeEmpty())
 					Expect(parsedCert.Subject.CommonName).To(Equal(request.CommonName))
+					Expect(cert.Chain).To(BeEmpty())
+				})
+
+				It("adds the intermediate CAs to the chain", func() {
+					root := cert
+					request.CommonName = "exampleIntermediate.com"
+					request.CA = root
+					intermediate, err := generator.GenerateCertificate("intermediate", request)
+					Expect(err).ToNot(HaveOccurred())
+
+					request.CommonName = "exampleIntermediate2.com"
+					request.CA = intermediate
+					intermediate2, err := generator.GenerateCertificate("intermediate2", request)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(intermediate2.Chain).To(Equal(intermediate.Certificate))
+
+					leaf, err := generator.GenerateCertificate("leaf", credsgen.CertificateGenerationRequest{CommonName: "leaf.example.com", CA: intermediate2})
+					Expect(err).ToNot(HaveOccurred())
+					Expect(leaf.Chain).To(Equal(append(append([]byte{}, intermediate2.Certificate...), intermediate.Certificate...)))
+					Expect(credsgen.VerifyChain(leaf, root.Certificate)).To(Succeed())
 				})
 			})
 		})
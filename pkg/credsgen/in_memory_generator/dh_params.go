@@ -0,0 +1,139 @@
+package inmemorygenerator
+
+import (
+	"encoding/asn1"
+	"encoding/pem"
+	"io"
+	"math/big"
+
+	"github.com/pkg/errors"
+
+	"code.cloudfoundry.org/quarks-utils/pkg/credsgen"
+)
+
+const (
+	// dhGenerator is the generator of the DH group, like the default of openssl dhparam
+	dhGenerator = 2
+	// minDHParamsBits and maxDHParamsBits limit the size of the DH prime
+	minDHParamsBits = 2048
+	maxDHParamsBits = 8192
+	// safePrimeAttempts is the number of candidates checked, before a new random start is read
+	safePrimeAttempts = 1 << 20
+	// sieveLimit is the bound of the primes, which sieve the candidates of safe primes
+	sieveLimit = 1 << 14
+)
+
+// sievePrimes are the odd primes below sieveLimit
+var sievePrimes = oddPrimesBelow(sieveLimit)
+
+// dhParameters is the PKCS #3 DHParameter structure, without the optional private value length
+type dhParameters struct {
+	P *big.Int
+	G *big.Int
+}
+
+// GenerateDHParams generates Diffie-Hellman parameters with a safe prime.
+// Finding a safe prime takes a while, like with openssl dhparam it often
+// takes more than ten seconds for the default size.
+func (g InMemoryGenerator) GenerateDHParams(name string, request credsgen.DHParamsGenerationRequest) (credsgen.DHParams, error) {
+	g.log.Debugf("Generating DH parameters %s", name)
+
+	bits := request.Bits
+	if bits == 0 {
+		bits = credsgen.DefaultDHParamsBits
+	}
+	if bits < minDHParamsBits {
+		return credsgen.DHParams{}, credsgen.Errorf(credsgen.ErrInvalidRequest, "DH parameters are too weak")
+	}
+	if bits > maxDHParamsBits {
+		return credsgen.DHParams{}, credsgen.Errorf(credsgen.ErrInvalidRequest, "DH parameters size too large")
+	}
+
+	p, err := safePrime(g.rand(), bits)
+	if err != nil {
+		return credsgen.DHParams{}, errors.Wrapf(err, "Generating DH prime failed for secret name %s", name)
+	}
+
+	der, err := asn1.Marshal(dhParameters{P: p, G: big.NewInt(dhGenerator)})
+	if err != nil {
+		return credsgen.DHParams{}, errors.Wrap(err, "marshaling DH parameters")
+	}
+	return credsgen.DHParams{
+		Parameters: pem.EncodeToMemory(&pem.Block{Type: "DH PARAMETERS", Bytes: der}),
+	}, nil
+}
+
+// safePrime returns a prime p of the given size, for which q = (p-1)/2 is
+// prime, too. Like openssl, p is 23 mod 24, so the generator 2 generates
+// the subgroup of order q.
+func safePrime(r io.Reader, bits int) (*big.Int, error) {
+	qBits := bits - 1
+	b := make([]byte, (qBits+7)/8)
+	one := big.NewInt(1)
+	two := big.NewInt(2)
+	residues := make([]uint64, len(sievePrimes))
+
+	for {
+		if _, err := io.ReadFull(r, b); err != nil {
+			return nil, credsgen.WrapError(credsgen.ErrBackendUnavailable, err, "reading random bytes")
+		}
+
+		q := new(big.Int).SetBytes(b)
+		for i := qBits; i < len(b)*8; i++ {
+			q.SetBit(q, i, 0)
+		}
+		q.SetBit(q, qBits-1, 1)
+		q.SetBit(q, qBits-2, 1)
+
+		// q is 11 mod 12, so p = 2q+1 is 23 mod 24
+		q.Sub(q, new(big.Int).Mod(q, big.NewInt(12)))
+		q.Add(q, big.NewInt(11))
+
+		mod := new(big.Int)
+		for i, prime := range sievePrimes {
+			residues[i] = mod.Mod(q, new(big.Int).SetUint64(prime)).Uint64()
+		}
+
+	NextDelta:
+		for delta := uint64(0); delta < safePrimeAttempts; delta += 12 {
+			for i, prime := range sievePrimes {
+				// neither q nor 2q+1 may be divisible by the prime
+				m := (residues[i] + delta) % prime
+				if m == 0 || m == (prime-1)/2 {
+					continue NextDelta
+				}
+			}
+
+			candidate := new(big.Int).Add(q, new(big.Int).SetUint64(delta))
+			if candidate.BitLen() != qBits {
+				break
+			}
+			p := new(big.Int).Lsh(candidate, 1)
+			p.SetBit(p, 0, 1)
+
+			// a Fermat test of p is cheap and rules out most candidates
+			if new(big.Int).Exp(two, new(big.Int).Sub(p, one), p).Cmp(one) != 0 {
+				continue
+			}
+			if candidate.ProbablyPrime(20) && p.ProbablyPrime(20) {
+				return p, nil
+			}
+		}
+	}
+}
+
+// oddPrimesBelow returns the odd primes below the limit, using the sieve of Eratosthenes
+func oddPrimesBelow(limit uint64) []uint64 {
+	composite := make([]bool, limit)
+	primes := []uint64{}
+	for i := uint64(3); i < limit; i += 2 {
+		if composite[i] {
+			continue
+		}
+		primes = append(primes, i)
+		for j := i * i; j < limit; j += 2 * i {
+			composite[j] = true
+		}
+	}
+	return primes
+}
@@ -0,0 +1,86 @@
+package inmemorygenerator_test
+
+import (
+	"encoding/asn1"
+	"encoding/pem"
+	"math/big"
+	"math/rand"
+
+	"github.com/pkg/errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"code.cloudfoundry.org/quarks-utils/pkg/credsgen"
+	inmemorygenerator "code.cloudfoundry.org/quarks-utils/pkg/credsgen/in_memory_generator"
+	helper "code.cloudfoundry.org/quarks-utils/testing/testhelper"
+)
+
+// dhParamsSeed is a seed, for which a 2048 bit safe prime is found quickly
+const dhParamsSeed = 5
+
+// seededDHParams caches the DH parameters of the seeded generator, since
+// finding a 2048 bit safe prime takes seconds
+var seededDHParams *credsgen.DHParams
+
+var _ = Describe("InMemoryGenerator", func() {
+	var (
+		generator *inmemorygenerator.InMemoryGenerator
+	)
+
+	generateSeeded := func() credsgen.DHParams {
+		_, log := helper.NewTestLogger()
+		g := inmemorygenerator.NewSeededInMemoryGenerator(log, rand.New(rand.NewSource(dhParamsSeed)), nil)
+		params, err := g.GenerateDHParams("foo", credsgen.DHParamsGenerationRequest{Bits: 2048})
+		Expect(err).ToNot(HaveOccurred())
+		return params
+	}
+
+	cachedSeeded := func() credsgen.DHParams {
+		if seededDHParams == nil {
+			params := generateSeeded()
+			seededDHParams = &params
+		}
+		return *seededDHParams
+	}
+
+	BeforeEach(func() {
+		_, log := helper.NewTestLogger()
+		generator = inmemorygenerator.NewInMemoryGenerator(log)
+	})
+
+	Describe("GenerateDHParams", func() {
+		It("generates a safe prime", func() {
+			params := cachedSeeded()
+
+			block, rest := pem.Decode(params.Parameters)
+			Expect(rest).To(BeEmpty())
+			Expect(block.Type).To(Equal("DH PARAMETERS"))
+
+			var parsed struct {
+				P *big.Int
+				G *big.Int
+			}
+			rest, err := asn1.Unmarshal(block.Bytes, &parsed)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(rest).To(BeEmpty())
+
+			Expect(parsed.G.Int64()).To(Equal(int64(2)))
+			Expect(parsed.P.BitLen()).To(Equal(2048))
+			Expect(parsed.P.ProbablyPrime(20)).To(BeTrue())
+			q := new(big.Int).Rsh(parsed.P, 1)
+			Expect(q.ProbablyPrime(20)).To(BeTrue())
+			Expect(new(big.Int).Mod(parsed.P, big.NewInt(24)).Int64()).To(Equal(int64(23)))
+		})
+
+		It("is deterministic for seeded generators", func() {
+			Expect(generateSeeded()).To(Equal(cachedSeeded()))
+		})
+
+		It("fails for weak parameters", func() {
+			_, err := generator.GenerateDHParams("foo", credsgen.DHParamsGenerationRequest{Bits: 1024})
+			Expect(err).To(MatchError(ContainSubstring("DH parameters are too weak")))
+			Expect(errors.Is(err, credsgen.ErrInvalidRequest)).To(BeTrue())
+		})
+	})
+})
@@ -0,0 +1,50 @@
+package inmemorygenerator
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/bcrypt"
+
+	"code.cloudfoundry.org/quarks-utils/pkg/credsgen"
+)
+
+// maxBcryptPasswordLength is the number of bytes bcrypt considers, longer passwords are truncated
+const maxBcryptPasswordLength = 72
+
+// GenerateHtpasswd generates an htpasswd entry with the bcrypt hash of the
+// password. The salt of the hash is random, even for seeded generators.
+func (g InMemoryGenerator) GenerateHtpasswd(name string, request credsgen.HtpasswdGenerationRequest) (credsgen.Htpasswd, error) {
+	g.log.Debugf("Generating htpasswd entry %s", name)
+
+	if request.Username == "" || strings.ContainsAny(request.Username, ":\n") {
+		return credsgen.Htpasswd{}, credsgen.Errorf(credsgen.ErrInvalidRequest, "invalid htpasswd username '%s'", request.Username)
+	}
+
+	cost := request.Cost
+	if cost == 0 {
+		cost = credsgen.DefaultHtpasswdCost
+	}
+	if cost < bcrypt.MinCost || cost > bcrypt.MaxCost {
+		return credsgen.Htpasswd{}, credsgen.Errorf(credsgen.ErrInvalidRequest, "bcrypt cost %d is not between %d and %d", cost, bcrypt.MinCost, bcrypt.MaxCost)
+	}
+
+	password := request.Password
+	if password == "" {
+		password = randomString(g.rand(), credsgen.DefaultPasswordLength)
+	}
+	if len(password) > maxBcryptPasswordLength {
+		return credsgen.Htpasswd{}, credsgen.Errorf(credsgen.ErrInvalidRequest, "htpasswd password is longer than %d bytes", maxBcryptPasswordLength)
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), cost)
+	if err != nil {
+		return credsgen.Htpasswd{}, errors.Wrapf(err, "Generating bcrypt hash failed for secret name %s", name)
+	}
+
+	return credsgen.Htpasswd{
+		Username: request.Username,
+		Password: password,
+		Entry:    request.Username + ":" + string(hash),
+	}, nil
+}
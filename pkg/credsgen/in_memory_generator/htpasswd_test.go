@@ -0,0 +1,66 @@
+package inmemorygenerator_test
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/bcrypt"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"code.cloudfoundry.org/quarks-utils/pkg/credsgen"
+	inmemorygenerator "code.cloudfoundry.org/quarks-utils/pkg/credsgen/in_memory_generator"
+	helper "code.cloudfoundry.org/quarks-utils/testing/testhelper"
+)
+
+var _ = Describe("InMemoryGenerator", func() {
+	var (
+		generator credsgen.Generator
+	)
+
+	BeforeEach(func() {
+		_, log := helper.NewTestLogger()
+		generator = inmemorygenerator.NewInMemoryGenerator(log)
+	})
+
+	Describe("GenerateHtpasswd", func() {
+		It("hashes the password with bcrypt", func() {
+			htpasswd, err := generator.GenerateHtpasswd("foo", credsgen.HtpasswdGenerationRequest{Username: "admin", Password: "secret", Cost: bcrypt.MinCost})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(htpasswd.Username).To(Equal("admin"))
+			Expect(htpasswd.Password).To(Equal("secret"))
+
+			parts := strings.SplitN(htpasswd.Entry, ":", 2)
+			Expect(parts[0]).To(Equal("admin"))
+			Expect(bcrypt.CompareHashAndPassword([]byte(parts[1]), []byte("secret"))).To(Succeed())
+			cost, err := bcrypt.Cost([]byte(parts[1]))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(cost).To(Equal(bcrypt.MinCost))
+		})
+
+		It("generates a password with the default cost", func() {
+			htpasswd, err := generator.GenerateHtpasswd("foo", credsgen.HtpasswdGenerationRequest{Username: "admin"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(htpasswd.Password).To(HaveLen(credsgen.DefaultPasswordLength))
+
+			hash := strings.TrimPrefix(htpasswd.Entry, "admin:")
+			Expect(bcrypt.CompareHashAndPassword([]byte(hash), []byte(htpasswd.Password))).To(Succeed())
+			cost, err := bcrypt.Cost([]byte(hash))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(cost).To(Equal(credsgen.DefaultHtpasswdCost))
+		})
+
+		It("fails for invalid requests", func() {
+			for _, request := range []credsgen.HtpasswdGenerationRequest{
+				{},
+				{Username: "ad:min"},
+				{Username: "admin", Cost: 100},
+				{Username: "admin", Password: strings.Repeat("a", 73)},
+			} {
+				_, err := generator.GenerateHtpasswd("foo", request)
+				Expect(errors.Is(err, credsgen.ErrInvalidRequest)).To(BeTrue(), "request %v", request)
+			}
+		})
+	})
+})
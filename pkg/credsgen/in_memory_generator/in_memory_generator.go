@@ -1,6 +1,11 @@
 package inmemorygenerator
 
 import (
+	"crypto"
+	"crypto/rand"
+	"io"
+	"time"
+
 	"go.uber.org/zap"
 )
 
@@ -9,12 +14,79 @@ import (
 type InMemoryGenerator struct {
 	Bits      int    // Key bits
 	Expiry    int    // Expiration (days)
-	Algorithm string // Algorithm type
+	Algorithm string // Algorithm type, rsa, ecdsa or ed25519
+
+	// Rand is the entropy source for passwords, keys, serial numbers and
+	// signatures. It defaults to crypto/rand.
+	// A generator with a seeded reader is deterministic and meant for test
+	// fixtures only: it generates byte-identical credentials for the same
+	// sequence of calls. Since the standard library does not generate the
+	// same keys for the same entropy, the keys of seeded generators are
+	// derived from the reader by the generator itself, which must not be
+	// used for production credentials.
+	Rand io.Reader
+	// Clock returns the current time, which is the start of the validity of
+	// certificates. It defaults to time.Now.
+	Clock func() time.Time
+	// Keys provides the private keys, instead of generating them on every
+	// call. It is not used by seeded generators.
+	Keys KeySource
 
 	log *zap.SugaredLogger
 }
 
+// KeySource provides private keys of an algorithm and size to the generator
+type KeySource interface {
+	PrivateKey(algorithm string, bits int) (crypto.Signer, error)
+}
+
 // NewInMemoryGenerator creates a default InMemoryGenerator
 func NewInMemoryGenerator(log *zap.SugaredLogger) *InMemoryGenerator {
 	return &InMemoryGenerator{Bits: 2048, Expiry: 365, Algorithm: "rsa", log: log}
 }
+
+// NewSeededInMemoryGenerator creates a default InMemoryGenerator, which reads
+// its entropy from rand and its time from clock, to generate reproducible
+// credentials for tests, see Rand
+func NewSeededInMemoryGenerator(log *zap.SugaredLogger, rand io.Reader, clock func() time.Time) *InMemoryGenerator {
+	g := NewInMemoryGenerator(log)
+	g.Rand = rand
+	g.Clock = clock
+	return g
+}
+
+// rand returns the entropy source of the generator
+func (g InMemoryGenerator) rand() io.Reader {
+	if g.Rand == nil {
+		return rand.Reader
+	}
+	return g.Rand
+}
+
+// signingRand returns the entropy source for signatures. The nonce of ECDSA
+// signatures is derived from the private key, the digest and this reader.
+// Seeded generators use a constant reader, so the signatures only depend on
+// the key and the signed data.
+func (g InMemoryGenerator) signingRand() io.Reader {
+	if g.Rand == nil {
+		return rand.Reader
+	}
+	return zeroReader{}
+}
+
+// now returns the current time of the generator's clock
+func (g InMemoryGenerator) now() time.Time {
+	if g.Clock == nil {
+		return time.Now()
+	}
+	return g.Clock()
+}
+
+type zeroReader struct{}
+
+func (zeroReader) Read(b []byte) (int, error) {
+	for i := range b {
+		b[i] = 0
+	}
+	return len(b), nil
+}
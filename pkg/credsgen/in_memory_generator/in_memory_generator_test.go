@@ -1,6 +1,9 @@
 package inmemorygenerator_test
 
 import (
+	"math/rand"
+	"time"
+
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
@@ -40,4 +43,84 @@ var _ = Describe("InMemoryGenerator", func() {
 			})
 		})
 	})
+
+	Describe("NewSeededInMemoryGenerator", func() {
+		var (
+			clock time.Time
+		)
+
+		seeded := func(seed int64) *inmemorygenerator.InMemoryGenerator {
+			_, log := helper.NewTestLogger()
+			return inmemorygenerator.NewSeededInMemoryGenerator(log, rand.New(rand.NewSource(seed)), func() time.Time { return clock })
+		}
+
+		type credentials struct {
+			password string
+			rsaKey   credsgen.RSAKey
+			sshKey   credsgen.SSHKey
+			ca       credsgen.Certificate
+			cert     credsgen.Certificate
+			csr      []byte
+			csrKey   []byte
+		}
+
+		generate := func(g *inmemorygenerator.InMemoryGenerator) credentials {
+			var err error
+			c := credentials{}
+			c.password = g.GeneratePassword("password", credsgen.PasswordGenerationRequest{})
+			c.rsaKey, err = g.GenerateRSAKey("rsa")
+			Expect(err).ToNot(HaveOccurred())
+			c.sshKey, err = g.GenerateSSHKey("ssh")
+			Expect(err).ToNot(HaveOccurred())
+
+			g.Algorithm = "ecdsa"
+			g.Bits = 256
+			c.ca, err = g.GenerateCertificate("ca", credsgen.CertificateGenerationRequest{CommonName: "example.com", IsCA: true})
+			Expect(err).ToNot(HaveOccurred())
+			c.cert, err = g.GenerateCertificate("cert", credsgen.CertificateGenerationRequest{CommonName: "foo.example.com", CA: c.ca})
+			Expect(err).ToNot(HaveOccurred())
+			c.csr, c.csrKey, err = g.GenerateCertificateSigningRequest(credsgen.CertificateGenerationRequest{CommonName: "bar.example.com"})
+			Expect(err).ToNot(HaveOccurred())
+			return c
+		}
+
+		BeforeEach(func() {
+			clock = time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC)
+		})
+
+		It("generates byte-identical credentials for the same seed", func() {
+			Expect(generate(seeded(1))).To(Equal(generate(seeded(1))))
+		})
+
+		It("generates different credentials for different seeds", func() {
+			first := generate(seeded(1))
+			second := generate(seeded(2))
+			Expect(first.password).ToNot(Equal(second.password))
+			Expect(first.rsaKey.PrivateKey).ToNot(Equal(second.rsaKey.PrivateKey))
+			Expect(first.cert.Certificate).ToNot(Equal(second.cert.Certificate))
+		})
+
+		It("generates RSA CA certificates", func() {
+			ca, err := seeded(1).GenerateCertificate("ca", credsgen.CertificateGenerationRequest{CommonName: "example.com", IsCA: true})
+			Expect(err).ToNot(HaveOccurred())
+
+			parsedCA, err := parseCert(ca.Certificate)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(parsedCA.CheckSignatureFrom(parsedCA)).To(Succeed())
+		})
+
+		It("uses the clock for the validity of certificates", func() {
+			g := seeded(1)
+			g.Algorithm = "ecdsa"
+			g.Bits = 256
+			g.Expiry = 10
+			ca, err := g.GenerateCertificate("ca", credsgen.CertificateGenerationRequest{CommonName: "example.com", IsCA: true})
+			Expect(err).ToNot(HaveOccurred())
+
+			parsedCA, err := parseCert(ca.Certificate)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(parsedCA.NotBefore).To(Equal(clock.Add(-5 * time.Minute)))
+			Expect(parsedCA.NotAfter).To(Equal(clock.Add(-5*time.Minute + 10*24*time.Hour)))
+		})
+	})
 })
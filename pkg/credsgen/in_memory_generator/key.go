@@ -0,0 +1,281 @@
+package inmemorygenerator
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/pem"
+	"io"
+	"math/big"
+
+	"github.com/pkg/errors"
+
+	"code.cloudfoundry.org/quarks-utils/pkg/credsgen"
+)
+
+// rsaPublicExponent is the public exponent of generated RSA keys
+const rsaPublicExponent = 65537
+
+// generateKey generates a private key of the configured algorithm and size for certificates
+func (g InMemoryGenerator) generateKey() (crypto.Signer, error) {
+	if g.Algorithm == "rsa" {
+		if g.Bits < 2048 {
+			return nil, credsgen.Errorf(credsgen.ErrInvalidRequest, "RSA key is too weak")
+		}
+		if g.Bits > 8192 {
+			return nil, credsgen.Errorf(credsgen.ErrInvalidRequest, "RSA key size too large")
+		}
+	}
+	return g.privateKey(g.Algorithm, g.Bits)
+}
+
+// generateRSAKey returns an RSA key of the size, for RSA and SSH keys
+func (g InMemoryGenerator) generateRSAKey(bits int) (*rsa.PrivateKey, error) {
+	key, err := g.privateKey("rsa", bits)
+	if err != nil {
+		return nil, err
+	}
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.Errorf("expected an RSA key, got %T", key)
+	}
+	return rsaKey, nil
+}
+
+// privateKey takes the private key from the key source, unless the
+// generator has none or is seeded
+func (g InMemoryGenerator) privateKey(algorithm string, bits int) (crypto.Signer, error) {
+	if g.Keys != nil && g.Rand == nil {
+		return g.Keys.PrivateKey(algorithm, bits)
+	}
+	return g.GeneratePrivateKey(algorithm, bits)
+}
+
+// GeneratePrivateKey generates a private key of the algorithm and size. The
+// size of ecdsa keys selects the curve, it's ignored for ed25519 keys. It
+// never uses the key source of the generator.
+func (g InMemoryGenerator) GeneratePrivateKey(algorithm string, bits int) (crypto.Signer, error) {
+	switch algorithm {
+	case "rsa":
+		return g.newRSAKey(bits)
+	case "ecdsa":
+		var curve elliptic.Curve
+		switch bits {
+		case 256:
+			curve = elliptic.P256()
+		case 384:
+			curve = elliptic.P384()
+		case 521:
+			curve = elliptic.P521()
+		default:
+			return nil, credsgen.Errorf(credsgen.ErrUnsupportedAlgorithm, "invalid curve for %d bits", bits)
+		}
+		return g.newECDSAKey(curve)
+	case "ed25519":
+		return g.newEd25519Key()
+	}
+	return nil, credsgen.Errorf(credsgen.ErrUnsupportedAlgorithm, "invalid algorithm '%s'", algorithm)
+}
+
+// newRSAKey generates an RSA key, see Rand for seeded generators
+func (g InMemoryGenerator) newRSAKey(bits int) (*rsa.PrivateKey, error) {
+	if g.Rand == nil {
+		return rsa.GenerateKey(g.rand(), bits)
+	}
+
+	e := big.NewInt(rsaPublicExponent)
+	for {
+		p, err := randomPrime(g.Rand, bits-bits/2, e)
+		if err != nil {
+			return nil, err
+		}
+		q, err := randomPrime(g.Rand, bits/2, e)
+		if err != nil {
+			return nil, err
+		}
+
+		n := new(big.Int).Mul(p, q)
+		if p.Cmp(q) == 0 || n.BitLen() != bits {
+			continue
+		}
+
+		one := big.NewInt(1)
+		totient := new(big.Int).Mul(new(big.Int).Sub(p, one), new(big.Int).Sub(q, one))
+		d := new(big.Int).ModInverse(e, totient)
+		if d == nil {
+			continue
+		}
+
+		key := &rsa.PrivateKey{
+			PublicKey: rsa.PublicKey{N: n, E: rsaPublicExponent},
+			D:         d,
+			Primes:    []*big.Int{p, q},
+		}
+		key.Precompute()
+		if err := key.Validate(); err != nil {
+			return nil, errors.Wrap(err, "validating RSA key")
+		}
+		return key, nil
+	}
+}
+
+// randomPrime returns a prime with the two most significant bits set, for
+// which p-1 is coprime to e
+func randomPrime(r io.Reader, bits int, e *big.Int) (*big.Int, error) {
+	b := make([]byte, (bits+7)/8)
+	one := big.NewInt(1)
+	for {
+		if _, err := io.ReadFull(r, b); err != nil {
+			return nil, credsgen.WrapError(credsgen.ErrBackendUnavailable, err, "reading random bytes")
+		}
+
+		p := new(big.Int).SetBytes(b)
+		for i := bits; i < len(b)*8; i++ {
+			p.SetBit(p, i, 0)
+		}
+		p.SetBit(p, bits-1, 1)
+		p.SetBit(p, bits-2, 1)
+		p.SetBit(p, 0, 1)
+
+		if new(big.Int).Mod(p, e).Cmp(one) == 0 {
+			continue
+		}
+		if p.ProbablyPrime(20) {
+			return p, nil
+		}
+	}
+}
+
+// newECDSAKey generates an ECDSA key, see Rand for seeded generators
+func (g InMemoryGenerator) newECDSAKey(curve elliptic.Curve) (*ecdsa.PrivateKey, error) {
+	if g.Rand == nil {
+		return ecdsa.GenerateKey(curve, g.rand())
+	}
+
+	params := curve.Params()
+	b := make([]byte, (params.BitSize+64+7)/8)
+	if _, err := io.ReadFull(g.Rand, b); err != nil {
+		return nil, credsgen.WrapError(credsgen.ErrBackendUnavailable, err, "reading random bytes")
+	}
+
+	// d is in [1, n-1], the extra 64 bits keep the bias negligible
+	n := new(big.Int).Sub(params.N, big.NewInt(1))
+	d := new(big.Int).SetBytes(b)
+	d.Mod(d, n)
+	d.Add(d, big.NewInt(1))
+
+	key := &ecdsa.PrivateKey{D: d}
+	key.PublicKey.Curve = curve
+	key.PublicKey.X, key.PublicKey.Y = curve.ScalarBaseMult(d.FillBytes(make([]byte, (params.BitSize+7)/8)))
+	return key, nil
+}
+
+// newEd25519Key generates an ed25519 key, see Rand for seeded generators
+func (g InMemoryGenerator) newEd25519Key() (ed25519.PrivateKey, error) {
+	if g.Rand == nil {
+		_, key, err := ed25519.GenerateKey(g.rand())
+		return key, err
+	}
+
+	seed := make([]byte, ed25519.SeedSize)
+	if _, err := io.ReadFull(g.Rand, seed); err != nil {
+		return nil, credsgen.WrapError(credsgen.ErrBackendUnavailable, err, "reading random bytes")
+	}
+	return ed25519.NewKeyFromSeed(seed), nil
+}
+
+// marshalPrivateKey returns RSA keys as PKCS#1, EC keys as SEC 1 and
+// ed25519 keys as PKCS#8 PEM
+func marshalPrivateKey(key crypto.Signer) ([]byte, error) {
+	switch key := key.(type) {
+	case *rsa.PrivateKey:
+		return pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}), nil
+	case *ecdsa.PrivateKey:
+		der, err := x509.MarshalECPrivateKey(key)
+		if err != nil {
+			return nil, errors.Wrap(err, "marshaling EC private key")
+		}
+		return pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}), nil
+	case ed25519.PrivateKey:
+		der, err := x509.MarshalPKCS8PrivateKey(key)
+		if err != nil {
+			return nil, errors.Wrap(err, "marshaling ed25519 private key")
+		}
+		return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+	}
+	return nil, errors.Errorf("unsupported private key type %T", key)
+}
+
+// parsePrivateKey parses a PEM encoded PKCS#1, SEC 1 or PKCS#8 private key
+func parsePrivateKey(privateKeyPEM []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(privateKeyPEM)
+	if block == nil {
+		return nil, errors.New("decoding private key PEM")
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParseECPrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing private key")
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, errors.Errorf("unsupported private key type %T", key)
+	}
+	return signer, nil
+}
+
+// signatureAlgorithm returns the signature algorithm for the key, which
+// matches its size
+func signatureAlgorithm(key crypto.Signer) x509.SignatureAlgorithm {
+	switch pub := key.Public().(type) {
+	case *rsa.PublicKey:
+		switch {
+		case pub.N.BitLen() >= 4096:
+			return x509.SHA512WithRSA
+		case pub.N.BitLen() >= 3072:
+			return x509.SHA384WithRSA
+		}
+		return x509.SHA256WithRSA
+	case *ecdsa.PublicKey:
+		switch pub.Curve {
+		case elliptic.P384():
+			return x509.ECDSAWithSHA384
+		case elliptic.P521():
+			return x509.ECDSAWithSHA512
+		}
+		return x509.ECDSAWithSHA256
+	case ed25519.PublicKey:
+		return x509.PureEd25519
+	}
+	return x509.UnknownSignatureAlgorithm
+}
+
+// subjectKeyID returns the SHA-1 hash of the public key, as in RFC 5280, section 4.2.1.2
+func subjectKeyID(pub crypto.PublicKey) ([]byte, error) {
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return nil, errors.Wrap(err, "marshaling public key")
+	}
+
+	var info struct {
+		Algorithm asn1.RawValue
+		PublicKey asn1.BitString
+	}
+	if _, err := asn1.Unmarshal(der, &info); err != nil {
+		return nil, errors.Wrap(err, "parsing public key")
+	}
+
+	sum := sha1.Sum(info.PublicKey.Bytes)
+	return sum[:], nil
+}
@@ -1,10 +1,14 @@
 package inmemorygenerator
 
 import (
+	"io"
+
 	"code.cloudfoundry.org/quarks-utils/pkg/credsgen"
-	"github.com/dchest/uniuri"
 )
 
+// passwordChars are the characters of generated passwords
+const passwordChars = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789"
+
 // GeneratePassword generates a random password
 func (g InMemoryGenerator) GeneratePassword(name string, request credsgen.PasswordGenerationRequest) string {
 	g.log.Debugf("Generating password %s", name)
@@ -14,5 +18,30 @@ func (g InMemoryGenerator) GeneratePassword(name string, request credsgen.Passwo
 		length = credsgen.DefaultPasswordLength
 	}
 
-	return uniuri.NewLen(length)
+	return randomString(g.rand(), length)
+}
+
+// randomString returns a string of passwordChars. Bytes which would skew the
+// distribution of the characters are skipped.
+func randomString(r io.Reader, length int) string {
+	maxByte := 255 - (256 % len(passwordChars))
+	b := make([]byte, length)
+	buf := make([]byte, length+(length/4))
+
+	i := 0
+	for {
+		if _, err := io.ReadFull(r, buf); err != nil {
+			panic("error reading random bytes: " + err.Error())
+		}
+		for _, c := range buf {
+			if int(c) > maxByte {
+				continue
+			}
+			b[i] = passwordChars[int(c)%len(passwordChars)]
+			i++
+			if i == length {
+				return string(b)
+			}
+		}
+	}
 }
@@ -1,7 +1,6 @@
 package inmemorygenerator
 
 import (
-	"crypto/rand"
 	"crypto/rsa"
 	"crypto/x509"
 	"encoding/pem"
@@ -15,7 +14,7 @@ func (g InMemoryGenerator) GenerateRSAKey(name string) (credsgen.RSAKey, error)
 	g.log.Debugf("Generating RSA key %s", name)
 
 	// generate private key
-	private, err := rsa.GenerateKey(rand.Reader, g.Bits)
+	private, err := g.generateRSAKey(g.Bits)
 	if err != nil {
 		return credsgen.RSAKey{}, errors.Wrapf(err, "Generating private key failed for secret name %s", name)
 	}
@@ -1,8 +1,6 @@
 package inmemorygenerator
 
 import (
-	"crypto/rand"
-	"crypto/rsa"
 	"crypto/x509"
 	"encoding/pem"
 
@@ -16,7 +14,7 @@ func (g InMemoryGenerator) GenerateSSHKey(name string) (credsgen.SSHKey, error)
 	g.log.Debugf("Generating SSH key %s", name)
 
 	// generate private key
-	private, err := rsa.GenerateKey(rand.Reader, g.Bits)
+	private, err := g.generateRSAKey(g.Bits)
 	if err != nil {
 		return credsgen.SSHKey{}, errors.Wrapf(err, "Generating ssh key failed for secret %s", name)
 	}
@@ -0,0 +1,253 @@
+package pooledgenerator
+
+import (
+	"context"
+	"crypto"
+	"fmt"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+const (
+	// DefaultSize is the default number of buffered keys per algorithm and size
+	DefaultSize = 4
+
+	// minRetryInterval is the time to wait before refilling a buffer after the first error
+	minRetryInterval = 100 * time.Millisecond
+	// maxRetryInterval is the maximum time to wait before refilling a buffer after errors
+	maxRetryInterval = time.Minute
+)
+
+// Options configure a KeyPool
+type Options struct {
+	// Size is the number of keys buffered per algorithm and size, defaults to DefaultSize
+	Size int
+	// Concurrency is the maximum number of keys generated in parallel,
+	// defaults to the number of CPUs. If it's greater than one, refills
+	// leave one slot to keys requested by PrivateKey.
+	Concurrency int
+}
+
+// GenerateFunc generates a private key of the algorithm and size
+type GenerateFunc func(algorithm string, bits int) (crypto.Signer, error)
+
+type keyType struct {
+	algorithm string
+	bits      int
+}
+
+func (t keyType) String() string {
+	return fmt.Sprintf("%s-%d", t.algorithm, t.bits)
+}
+
+// KeyPool keeps a buffer of pre-generated private keys for each algorithm
+// and size passed to Warm, which is refilled in the background until the
+// context of the pool is done. Keys are generated on demand, if the buffer
+// is empty or the key type isn't pooled.
+type KeyPool struct {
+	ctx      context.Context
+	log      *zap.SugaredLogger
+	generate GenerateFunc
+	size     int
+	limiter  *limiter
+
+	mu      sync.Mutex
+	buffers map[keyType]chan crypto.Signer
+}
+
+// NewKeyPool creates a KeyPool, which generates its keys with generate
+func NewKeyPool(ctx context.Context, log *zap.SugaredLogger, generate GenerateFunc, options Options) *KeyPool {
+	if options.Size < 1 {
+		options.Size = DefaultSize
+	}
+	if options.Concurrency < 1 {
+		options.Concurrency = runtime.NumCPU()
+	}
+
+	return &KeyPool{
+		ctx:      ctx,
+		log:      log,
+		generate: generate,
+		size:     options.Size,
+		limiter:  newLimiter(ctx, options.Concurrency),
+		buffers:  map[keyType]chan crypto.Signer{},
+	}
+}
+
+// Warm starts to pre-generate keys of the algorithm and size in the background.
+// Only warmed key types are pooled.
+func (p *KeyPool) Warm(algorithm string, bits int) {
+	t := keyType{algorithm: algorithm, bits: bits}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if _, ok := p.buffers[t]; ok {
+		return
+	}
+	buffer := make(chan crypto.Signer, p.size)
+	p.buffers[t] = buffer
+	go p.fill(t, buffer)
+}
+
+// Len returns the number of buffered keys of the algorithm and size
+func (p *KeyPool) Len(algorithm string, bits int) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.buffers[keyType{algorithm: algorithm, bits: bits}])
+}
+
+// PrivateKey returns a buffered key of the algorithm and size, or generates
+// one if there is none. Keys generated on demand take precedence over refills.
+// After the context of the pool is done, the remaining buffered keys are
+// returned, before keys are generated on demand only.
+func (p *KeyPool) PrivateKey(algorithm string, bits int) (crypto.Signer, error) {
+	t := keyType{algorithm: algorithm, bits: bits}
+
+	p.mu.Lock()
+	buffer := p.buffers[t]
+	p.mu.Unlock()
+
+	select {
+	case key := <-buffer:
+		return key, nil
+	default:
+	}
+
+	if buffer != nil {
+		p.log.Debugf("Key pool for %s is empty, generating key", t)
+	}
+	return p.generateKey(t, true)
+}
+
+// fill generates keys until the context is done, blocking while the buffer
+// is full. Errors are retried with an exponential backoff.
+func (p *KeyPool) fill(t keyType, buffer chan crypto.Signer) {
+	retryInterval := minRetryInterval
+	for {
+		key, err := p.generateKey(t, false)
+		if err != nil {
+			if p.ctx.Err() != nil {
+				return
+			}
+			p.log.Errorf("Failed to pre-generate %s key, retrying in %s: %v", t, retryInterval, err)
+
+			timer := time.NewTimer(retryInterval)
+			select {
+			case <-timer.C:
+			case <-p.ctx.Done():
+				timer.Stop()
+				return
+			}
+
+			retryInterval *= 2
+			if retryInterval > maxRetryInterval {
+				retryInterval = maxRetryInterval
+			}
+			continue
+		}
+		retryInterval = minRetryInterval
+
+		select {
+		case buffer <- key:
+		case <-p.ctx.Done():
+			return
+		}
+	}
+}
+
+// generateKey generates a key, while not more than the configured number of
+// keys are generated. Once the context of the pool is done, refills fail and
+// keys requested by PrivateKey are generated directly.
+func (p *KeyPool) generateKey(t keyType, foreground bool) (crypto.Signer, error) {
+	if err := p.limiter.acquire(foreground); err != nil {
+		if foreground {
+			return p.generate(t.algorithm, t.bits)
+		}
+		return nil, errors.Wrapf(err, "generating %s key", t)
+	}
+	defer p.limiter.release(foreground)
+
+	return p.generate(t.algorithm, t.bits)
+}
+
+// limiter bounds the number of keys generated in parallel. Foreground
+// requests are admitted before any waiting refill, and refills leave one
+// slot free for foreground requests if the limit is greater than one.
+type limiter struct {
+	ctx   context.Context
+	limit int
+
+	mu         sync.Mutex
+	cond       *sync.Cond
+	running    int
+	background int
+	waiting    int
+}
+
+func newLimiter(ctx context.Context, limit int) *limiter {
+	l := &limiter{ctx: ctx, limit: limit}
+	l.cond = sync.NewCond(&l.mu)
+
+	go func() {
+		<-ctx.Done()
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		l.cond.Broadcast()
+	}()
+	return l
+}
+
+// acquire blocks until a slot is free or the context is done
+func (l *limiter) acquire(foreground bool) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if foreground {
+		l.waiting++
+		defer func() { l.waiting-- }()
+	}
+
+	for l.ctx.Err() == nil && !l.admits(foreground) {
+		l.cond.Wait()
+	}
+	if err := l.ctx.Err(); err != nil {
+		return err
+	}
+
+	l.running++
+	if !foreground {
+		l.background++
+	}
+	return nil
+}
+
+// admits returns true if a request may start now, must be called with mu held
+func (l *limiter) admits(foreground bool) bool {
+	if l.running >= l.limit {
+		return false
+	}
+	if foreground {
+		return true
+	}
+	if l.waiting > 0 {
+		return false
+	}
+	return l.limit == 1 || l.background < l.limit-1
+}
+
+// release frees the slot of a request started by acquire
+func (l *limiter) release(foreground bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.running--
+	if !foreground {
+		l.background--
+	}
+	l.cond.Broadcast()
+}
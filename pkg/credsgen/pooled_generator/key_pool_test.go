@@ -0,0 +1,176 @@
+package pooledgenerator_test
+
+import (
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	pooledgenerator "code.cloudfoundry.org/quarks-utils/pkg/credsgen/pooled_generator"
+	helper "code.cloudfoundry.org/quarks-utils/testing/testhelper"
+)
+
+var _ = Describe("KeyPool", func() {
+	var (
+		ctx    context.Context
+		cancel context.CancelFunc
+		pool   *pooledgenerator.KeyPool
+
+		mu       sync.Mutex
+		running  int
+		maxRun   int
+		warmed   int
+		maxWarm  int
+		failures int
+	)
+
+	generate := func(algorithm string, bits int) (crypto.Signer, error) {
+		mu.Lock()
+		running++
+		if running > maxRun {
+			maxRun = running
+		}
+		// bits below 4 are only used for warmed key types
+		if bits < 4 {
+			warmed++
+			if warmed > maxWarm {
+				maxWarm = warmed
+			}
+		}
+		failed := failures > 0
+		if failed {
+			failures--
+		}
+		mu.Unlock()
+
+		defer func() {
+			mu.Lock()
+			running--
+			if bits < 4 {
+				warmed--
+			}
+			mu.Unlock()
+		}()
+
+		if algorithm != "ed25519" {
+			return nil, errors.New("invalid algorithm")
+		}
+		if failed {
+			return nil, errors.New("temporary failure")
+		}
+		time.Sleep(5 * time.Millisecond)
+		_, key, err := ed25519.GenerateKey(nil)
+		return key, err
+	}
+
+	BeforeEach(func() {
+		mu.Lock()
+		running, maxRun = 0, 0
+		warmed, maxWarm = 0, 0
+		failures = 0
+		mu.Unlock()
+
+		ctx, cancel = context.WithCancel(context.Background())
+		_, log := helper.NewTestLogger()
+		pool = pooledgenerator.NewKeyPool(ctx, log, generate, pooledgenerator.Options{Size: 3, Concurrency: 2})
+	})
+
+	AfterEach(func() {
+		cancel()
+	})
+
+	It("pre-generates keys in the background", func() {
+		pool.Warm("ed25519", 256)
+		Eventually(func() int { return pool.Len("ed25519", 256) }).Should(Equal(3))
+
+		key, err := pool.PrivateKey("ed25519", 256)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(key).To(BeAssignableToTypeOf(ed25519.PrivateKey{}))
+		Eventually(func() int { return pool.Len("ed25519", 256) }).Should(Equal(3))
+	})
+
+	It("generates keys on demand if the buffer is empty", func() {
+		key, err := pool.PrivateKey("ed25519", 128)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(key).ToNot(BeNil())
+	})
+
+	It("only pools warmed key types", func() {
+		_, err := pool.PrivateKey("ed25519", 128)
+		Expect(err).ToNot(HaveOccurred())
+		Consistently(func() int { return pool.Len("ed25519", 128) }, 100*time.Millisecond).Should(Equal(0))
+	})
+
+	It("keeps pre-generating keys after errors", func() {
+		mu.Lock()
+		failures = 2
+		mu.Unlock()
+
+		pool.Warm("ed25519", 256)
+		Eventually(func() int { return pool.Len("ed25519", 256) }, 2*time.Second).Should(Equal(3))
+	})
+
+	It("bounds the number of keys generated in parallel", func() {
+		pool.Warm("ed25519", 1)
+		pool.Warm("ed25519", 2)
+		pool.Warm("ed25519", 3)
+
+		wg := sync.WaitGroup{}
+		for i := 0; i < 5; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				defer GinkgoRecover()
+				_, err := pool.PrivateKey("ed25519", 4)
+				Expect(err).ToNot(HaveOccurred())
+			}()
+		}
+		wg.Wait()
+
+		Eventually(func() int { return pool.Len("ed25519", 3) }).Should(Equal(3))
+		mu.Lock()
+		defer mu.Unlock()
+		Expect(maxRun).To(BeNumerically("<=", 2))
+	})
+
+	It("leaves a slot to keys requested on demand", func() {
+		pool.Warm("ed25519", 1)
+		pool.Warm("ed25519", 2)
+		pool.Warm("ed25519", 3)
+
+		for i := 0; i < 3; i++ {
+			_, err := pool.PrivateKey("ed25519", 4)
+			Expect(err).ToNot(HaveOccurred())
+		}
+
+		Eventually(func() int { return pool.Len("ed25519", 3) }).Should(Equal(3))
+		mu.Lock()
+		defer mu.Unlock()
+		Expect(maxWarm).To(Equal(1))
+	})
+
+	It("returns generation errors", func() {
+		_, err := pool.PrivateKey("dsa", 1024)
+		Expect(err).To(MatchError("invalid algorithm"))
+	})
+
+	It("stops pre-generating when the context is done", func() {
+		pool.Warm("ed25519", 256)
+		Eventually(func() int { return pool.Len("ed25519", 256) }).Should(Equal(3))
+		cancel()
+
+		// buffered keys are still returned, then keys are generated on demand
+		for i := 0; i < 5; i++ {
+			key, err := pool.PrivateKey("ed25519", 256)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(key).ToNot(BeNil())
+		}
+		Consistently(func() int { return pool.Len("ed25519", 256) }, 100*time.Millisecond).Should(Equal(0))
+	})
+})
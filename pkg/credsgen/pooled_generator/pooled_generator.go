@@ -0,0 +1,40 @@
+// Package pooledgenerator implements a credsgen.Generator, which takes its
+// private keys from a pool of keys pre-generated in the background
+package pooledgenerator
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+
+	"code.cloudfoundry.org/quarks-utils/pkg/credsgen"
+	inmemorygenerator "code.cloudfoundry.org/quarks-utils/pkg/credsgen/in_memory_generator"
+)
+
+// PooledGenerator wraps an InMemoryGenerator, so certificates, RSA and SSH
+// keys use pre-generated private keys
+type PooledGenerator struct {
+	*inmemorygenerator.InMemoryGenerator
+
+	Pool *KeyPool
+}
+
+var _ credsgen.Generator = &PooledGenerator{}
+
+// NewPooledGenerator creates a PooledGenerator, which uses a copy of the
+// generator. The pool is warmed for the algorithm and size of the generator,
+// and stops pre-generating when the context is done.
+// Seeded generators don't use the pool, to keep their keys reproducible.
+func NewPooledGenerator(ctx context.Context, log *zap.SugaredLogger, generator *inmemorygenerator.InMemoryGenerator, options Options) *PooledGenerator {
+	unpooled := *generator
+	unpooled.Keys = nil
+
+	pool := NewKeyPool(ctx, log, unpooled.GeneratePrivateKey, options)
+	if generator.Rand == nil {
+		pool.Warm(generator.Algorithm, generator.Bits)
+	}
+
+	pooled := unpooled
+	pooled.Keys = pool
+	return &PooledGenerator{InMemoryGenerator: &pooled, Pool: pool}
+}
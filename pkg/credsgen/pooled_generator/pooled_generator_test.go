@@ -0,0 +1,86 @@
+package pooledgenerator_test
+
+import (
+	"context"
+	"math/rand"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"code.cloudfoundry.org/quarks-utils/pkg/credsgen"
+	inmemorygenerator "code.cloudfoundry.org/quarks-utils/pkg/credsgen/in_memory_generator"
+	pooledgenerator "code.cloudfoundry.org/quarks-utils/pkg/credsgen/pooled_generator"
+	helper "code.cloudfoundry.org/quarks-utils/testing/testhelper"
+)
+
+var _ = Describe("PooledGenerator", func() {
+	var (
+		ctx       context.Context
+		cancel    context.CancelFunc
+		generator *inmemorygenerator.InMemoryGenerator
+		pooled    *pooledgenerator.PooledGenerator
+	)
+
+	BeforeEach(func() {
+		ctx, cancel = context.WithCancel(context.Background())
+		_, log := helper.NewTestLogger()
+		generator = inmemorygenerator.NewInMemoryGenerator(log)
+		generator.Algorithm = "ecdsa"
+		generator.Bits = 256
+		pooled = pooledgenerator.NewPooledGenerator(ctx, log, generator, pooledgenerator.Options{Size: 2})
+	})
+
+	AfterEach(func() {
+		cancel()
+	})
+
+	It("warms the pool for the key type of the generator", func() {
+		Eventually(func() int { return pooled.Pool.Len("ecdsa", 256) }).Should(Equal(2))
+		Consistently(func() int { return pooled.Pool.Len("rsa", 256) }, 100*time.Millisecond).Should(Equal(0))
+	})
+
+	It("generates keys after the context is done", func() {
+		cancel()
+
+		_, err := pooled.GenerateCertificate("ca", credsgen.CertificateGenerationRequest{CommonName: "ca", IsCA: true})
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("generates certificates with pooled keys", func() {
+		Eventually(func() int { return pooled.Pool.Len("ecdsa", 256) }).Should(Equal(2))
+
+		ca, err := pooled.GenerateCertificate("ca", credsgen.CertificateGenerationRequest{CommonName: "ca", IsCA: true})
+		Expect(err).ToNot(HaveOccurred())
+		_, err = pooled.GenerateCertificate("cert", credsgen.CertificateGenerationRequest{CommonName: "foo", CA: ca})
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("generates RSA and SSH keys with pooled keys", func() {
+		pooled.Bits = 2048
+		pooled.Pool.Warm("rsa", 2048)
+
+		key, err := pooled.GenerateRSAKey("rsa")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(key.PrivateKey).To(ContainSubstring("BEGIN RSA PRIVATE KEY"))
+		Eventually(func() int { return pooled.Pool.Len("rsa", 2048) }, 30*time.Second).Should(Equal(2))
+
+		sshKey, err := pooled.GenerateSSHKey("ssh")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(sshKey.PublicKey).To(MatchRegexp("ssh-rsa\\s.+"))
+	})
+
+	It("does not change the wrapped generator", func() {
+		Expect(generator.Keys).To(BeNil())
+	})
+
+	It("does not use the pool for seeded generators", func() {
+		_, log := helper.NewTestLogger()
+		generator.Rand = rand.New(rand.NewSource(1))
+		seeded := pooledgenerator.NewPooledGenerator(ctx, log, generator, pooledgenerator.Options{})
+
+		_, err := seeded.GenerateCertificate("ca", credsgen.CertificateGenerationRequest{CommonName: "ca", IsCA: true})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(seeded.Pool.Len("ecdsa", 256)).To(BeZero())
+	})
+})
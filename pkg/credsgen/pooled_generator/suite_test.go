@@ -0,0 +1,13 @@
+package pooledgenerator_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestPooledGenerator(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "PooledGenerator Suite")
+}
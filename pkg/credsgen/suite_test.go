@@ -0,0 +1,13 @@
+package credsgen_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestCredsgen(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Credsgen Suite")
+}
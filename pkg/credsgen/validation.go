@@ -0,0 +1,187 @@
+package credsgen
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ValidationCheck names a check of the certificate validation
+type ValidationCheck string
+
+const (
+	// KeyMatchCheck checks that the private key belongs to the certificate
+	KeyMatchCheck ValidationCheck = "key-match"
+	// ChainCheck checks that the certificate chains to the CA
+	ChainCheck ValidationCheck = "chain"
+	// HostsCheck checks that the certificate is valid for the required hosts
+	HostsCheck ValidationCheck = "hosts"
+	// KeySizeCheck checks the minimum size of the certificate's key
+	KeySizeCheck ValidationCheck = "key-size"
+	// ValidityCheck checks the remaining validity of the certificate
+	ValidityCheck ValidationCheck = "validity"
+)
+
+// CertificateValidationRequest specifies the checks for an existing certificate, e.g. one provided by a user
+type CertificateValidationRequest struct {
+	// CA the certificate has to chain to. Its certificate may contain
+	// several CAs, like a CA bundle. The check is skipped if it's empty.
+	CA Certificate
+	// Hosts are the DNS names and IP addresses, which the certificate has to be valid for
+	Hosts []string
+	// MinKeySize is the minimum size of the key in bits, like 2048 for RSA
+	// or 256 for ECDSA keys. The check is skipped if it's zero.
+	MinKeySize int
+	// MinValidity is the remaining validity the certificate needs. Expired
+	// certificates are always reported.
+	MinValidity time.Duration
+	// Now is the time of the validation, it defaults to the current time
+	Now time.Time
+}
+
+// Finding is a problem of a validated certificate
+type Finding struct {
+	Check   ValidationCheck
+	Message string
+}
+
+func (f Finding) String() string {
+	return fmt.Sprintf("%s: %s", f.Check, f.Message)
+}
+
+// Findings are the problems of a validated certificate
+type Findings []Finding
+
+// Has returns whether one of the findings is of the check
+func (f Findings) Has(check ValidationCheck) bool {
+	for _, finding := range f {
+		if finding.Check == check {
+			return true
+		}
+	}
+	return false
+}
+
+// Err returns an ErrInvalidRequest error listing the findings, or nil if there are none
+func (f Findings) Err() error {
+	if len(f) == 0 {
+		return nil
+	}
+
+	messages := make([]string, 0, len(f))
+	for _, finding := range f {
+		messages = append(messages, finding.String())
+	}
+	return Errorf(ErrInvalidRequest, "certificate validation failed: %s", strings.Join(messages, "; "))
+}
+
+// ValidateCertificate checks the certificate and its private key against
+// the request. It returns the findings of all failed checks, which are
+// empty for a valid certificate. An error is only returned if the
+// certificate can't be parsed at all.
+func ValidateCertificate(cert Certificate, request CertificateValidationRequest) (Findings, error) {
+	certs, err := parseCertificates(cert.Certificate)
+	if err != nil {
+		return nil, WrapError(ErrInvalidRequest, err, "parsing certificate")
+	}
+	leaf := certs[0]
+
+	now := request.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	findings := Findings{}
+	if message := checkKeyMatch(leaf, cert.PrivateKey); message != "" {
+		findings = append(findings, Finding{Check: KeyMatchCheck, Message: message})
+	}
+
+	if len(request.CA.Certificate) > 0 {
+		// The validity of the leaf is reported by the validity check, so the
+		// chain is verified at a time the leaf is valid
+		if err := verifyChain(cert, request.CA.Certificate, withinValidity(leaf, now)); err != nil {
+			findings = append(findings, Finding{Check: ChainCheck, Message: err.Error()})
+		}
+	}
+
+	for _, host := range request.Hosts {
+		if err := leaf.VerifyHostname(host); err != nil {
+			findings = append(findings, Finding{Check: HostsCheck, Message: fmt.Sprintf("certificate is not valid for '%s'", host)})
+		}
+	}
+
+	if request.MinKeySize > 0 {
+		size := keySize(leaf.PublicKey)
+		if size < request.MinKeySize {
+			findings = append(findings, Finding{
+				Check:   KeySizeCheck,
+				Message: fmt.Sprintf("%s key size %d is smaller than %d", leaf.PublicKeyAlgorithm, size, request.MinKeySize),
+			})
+		}
+	}
+
+	switch {
+	case now.Before(leaf.NotBefore):
+		findings = append(findings, Finding{Check: ValidityCheck, Message: fmt.Sprintf("certificate is not valid before %s", leaf.NotBefore)})
+	case !now.Before(leaf.NotAfter):
+		findings = append(findings, Finding{Check: ValidityCheck, Message: fmt.Sprintf("certificate expired at %s", leaf.NotAfter)})
+	case leaf.NotAfter.Sub(now) < request.MinValidity:
+		findings = append(findings, Finding{
+			Check:   ValidityCheck,
+			Message: fmt.Sprintf("certificate expires at %s, which is less than %s from now", leaf.NotAfter, request.MinValidity),
+		})
+	}
+
+	return findings, nil
+}
+
+// withinValidity returns t, or the closest time within the validity period of the certificate
+func withinValidity(cert *x509.Certificate, t time.Time) time.Time {
+	if t.Before(cert.NotBefore) {
+		return cert.NotBefore
+	}
+	if t.After(cert.NotAfter) {
+		return cert.NotAfter
+	}
+	return t
+}
+
+// checkKeyMatch returns a message if the private key is invalid or does not belong to the certificate
+func checkKeyMatch(cert *x509.Certificate, privateKeyPEM []byte) string {
+	if len(privateKeyPEM) == 0 {
+		return "private key is missing"
+	}
+
+	key, err := parsePrivateKey(privateKeyPEM)
+	if err != nil {
+		return err.Error()
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return fmt.Sprintf("unsupported private key type %T", key)
+	}
+
+	pub, ok := signer.Public().(interface{ Equal(crypto.PublicKey) bool })
+	if !ok || !pub.Equal(cert.PublicKey) {
+		return "private key does not match the certificate"
+	}
+	return ""
+}
+
+// keySize returns the size of the public key in bits
+func keySize(pub crypto.PublicKey) int {
+	switch k := pub.(type) {
+	case *rsa.PublicKey:
+		return k.N.BitLen()
+	case *ecdsa.PublicKey:
+		return k.Curve.Params().BitSize
+	case ed25519.PublicKey:
+		return 8 * len(k)
+	}
+	return 0
+}
@@ -0,0 +1,159 @@
+package credsgen_test
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"code.cloudfoundry.org/quarks-utils/pkg/credsgen"
+	inmemorygenerator "code.cloudfoundry.org/quarks-utils/pkg/credsgen/in_memory_generator"
+	helper "code.cloudfoundry.org/quarks-utils/testing/testhelper"
+)
+
+var _ = Describe("ValidateCertificate", func() {
+	var (
+		generator *inmemorygenerator.InMemoryGenerator
+		ca        credsgen.Certificate
+		cert      credsgen.Certificate
+		request   credsgen.CertificateValidationRequest
+	)
+
+	BeforeEach(func() {
+		_, log := helper.NewTestLogger()
+		generator = inmemorygenerator.NewInMemoryGenerator(log)
+		generator.Algorithm = "ecdsa"
+		generator.Bits = 256
+
+		var err error
+		ca, err = generator.GenerateCertificate("ca", credsgen.CertificateGenerationRequest{CommonName: "Example CA", IsCA: true})
+		Expect(err).ToNot(HaveOccurred())
+		cert, err = generator.GenerateCertificate("leaf", credsgen.CertificateGenerationRequest{
+			CommonName:       "example.com",
+			AlternativeNames: []string{"*.example.com", "10.0.0.1"},
+			CA:               ca,
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		request = credsgen.CertificateValidationRequest{
+			CA:          ca,
+			Hosts:       []string{"example.com", "www.example.com", "10.0.0.1"},
+			MinKeySize:  256,
+			MinValidity: 30 * 24 * time.Hour,
+		}
+	})
+
+	It("has no findings for a valid certificate", func() {
+		findings, err := credsgen.ValidateCertificate(cert, request)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(findings).To(BeEmpty())
+		Expect(findings.Err()).ToNot(HaveOccurred())
+	})
+
+	It("finds keys not matching the certificate", func() {
+		cert.PrivateKey = ca.PrivateKey
+
+		findings, err := credsgen.ValidateCertificate(cert, request)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(findings).To(Equal(credsgen.Findings{{Check: credsgen.KeyMatchCheck, Message: "private key does not match the certificate"}}))
+	})
+
+	It("finds missing keys", func() {
+		cert.PrivateKey = nil
+
+		findings, err := credsgen.ValidateCertificate(cert, request)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(findings.Has(credsgen.KeyMatchCheck)).To(BeTrue())
+	})
+
+	It("finds certificates not issued by the CA", func() {
+		other, err := generator.GenerateCertificate("other", credsgen.CertificateGenerationRequest{CommonName: "Other CA", IsCA: true})
+		Expect(err).ToNot(HaveOccurred())
+		request.CA = other
+
+		findings, err := credsgen.ValidateCertificate(cert, request)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(findings).To(HaveLen(1))
+		Expect(findings[0].Check).To(Equal(credsgen.ChainCheck))
+	})
+
+	It("skips the chain check without a CA", func() {
+		request.CA = credsgen.Certificate{}
+
+		findings, err := credsgen.ValidateCertificate(cert, request)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(findings).To(BeEmpty())
+	})
+
+	It("finds hosts not covered by the certificate", func() {
+		request.Hosts = append(request.Hosts, "example.org", "sub.www.example.com", "10.0.0.2")
+
+		findings, err := credsgen.ValidateCertificate(cert, request)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(findings).To(Equal(credsgen.Findings{
+			{Check: credsgen.HostsCheck, Message: "certificate is not valid for 'example.org'"},
+			{Check: credsgen.HostsCheck, Message: "certificate is not valid for 'sub.www.example.com'"},
+			{Check: credsgen.HostsCheck, Message: "certificate is not valid for '10.0.0.2'"},
+		}))
+	})
+
+	It("finds keys which are too small", func() {
+		request.MinKeySize = 384
+
+		findings, err := credsgen.ValidateCertificate(cert, request)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(findings).To(Equal(credsgen.Findings{{Check: credsgen.KeySizeCheck, Message: "ECDSA key size 256 is smaller than 384"}}))
+	})
+
+	It("finds certificates which expire soon", func() {
+		request.MinValidity = 400 * 24 * time.Hour
+
+		findings, err := credsgen.ValidateCertificate(cert, request)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(findings).To(HaveLen(1))
+		Expect(findings[0].Check).To(Equal(credsgen.ValidityCheck))
+		Expect(findings[0].Message).To(ContainSubstring("less than 9600h0m0s from now"))
+	})
+
+	It("finds expired certificates", func() {
+		request.Now = time.Now().Add(2 * 365 * 24 * time.Hour)
+
+		findings, err := credsgen.ValidateCertificate(cert, request)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(findings).To(HaveLen(1))
+		Expect(findings[0].Check).To(Equal(credsgen.ValidityCheck))
+
+		err = findings.Err()
+		Expect(err).To(MatchError(ContainSubstring("validity: certificate expired at")))
+		Expect(errors.Is(err, credsgen.ErrInvalidRequest)).To(BeTrue())
+	})
+
+	It("finds certificates which are not valid yet", func() {
+		request.Now = time.Now().Add(-24 * time.Hour)
+
+		findings, err := credsgen.ValidateCertificate(cert, request)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(findings).To(HaveLen(1))
+		Expect(findings[0].Message).To(ContainSubstring("certificate is not valid before"))
+	})
+
+	It("still finds chain problems of expired certificates", func() {
+		other, err := generator.GenerateCertificate("other", credsgen.CertificateGenerationRequest{CommonName: "Other CA", IsCA: true})
+		Expect(err).ToNot(HaveOccurred())
+		request.CA = other
+		request.Now = time.Now().Add(2 * 365 * 24 * time.Hour)
+
+		findings, err := credsgen.ValidateCertificate(cert, request)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(findings).To(HaveLen(2))
+		Expect(findings.Has(credsgen.ChainCheck)).To(BeTrue())
+		Expect(findings.Has(credsgen.ValidityCheck)).To(BeTrue())
+	})
+
+	It("fails for invalid certificates", func() {
+		_, err := credsgen.ValidateCertificate(credsgen.Certificate{Certificate: []byte("invalid")}, request)
+		Expect(errors.Is(err, credsgen.ErrInvalidRequest)).To(BeTrue())
+	})
+})
@@ -5,90 +5,140 @@ import (
 	corev1 "k8s.io/api/core/v1"
 )
 
-// GetSecretRefFromPodSpec returns a list of all names for Secrets referenced by the object
-func GetSecretRefFromPodSpec(object corev1.PodSpec) map[string]bool {
-	result := map[string]bool{}
+// Kind is the kind of object a reference in a pod spec points to
+type Kind string
 
-	// Look at all volumes
-	for _, volume := range object.Volumes {
-		if volume.VolumeSource.Secret != nil {
-			result[volume.VolumeSource.Secret.SecretName] = true
-		}
+const (
+	// KindSecret is a reference to a Secret
+	KindSecret Kind = "Secret"
+	// KindConfigMap is a reference to a ConfigMap
+	KindConfigMap Kind = "ConfigMap"
+)
+
+// WalkFunc is called for every reference in a pod spec. The name points
+// into the pod spec, so it can be used to rewrite the reference.
+type WalkFunc func(kind Kind, name *string)
+
+// Walk calls fn for every place in the pod spec, which references a Secret or
+// a ConfigMap: volumes (including projected and CSI volumes, and the secret
+// refs of other volume plugins), env and envFrom of init, ephemeral and
+// regular containers, and image pull secrets.
+func Walk(spec *corev1.PodSpec, fn WalkFunc) {
+	for i := range spec.Volumes {
+		walkVolume(&spec.Volumes[i].VolumeSource, fn)
 	}
 
-	// Look at all init containers
-	for _, container := range object.InitContainers {
-		for _, envFrom := range container.EnvFrom {
-			if envFrom.SecretRef != nil {
-				result[envFrom.SecretRef.Name] = true
-			}
-		}
+	for i := range spec.InitContainers {
+		walkEnv(spec.InitContainers[i].Env, spec.InitContainers[i].EnvFrom, fn)
+	}
 
-		for _, envVar := range container.Env {
-			if envVar.ValueFrom != nil && envVar.ValueFrom.SecretKeyRef != nil {
-				result[envVar.ValueFrom.SecretKeyRef.Name] = true
-			}
-		}
+	for i := range spec.Containers {
+		walkEnv(spec.Containers[i].Env, spec.Containers[i].EnvFrom, fn)
 	}
 
-	// Look at all containers
-	for _, container := range object.Containers {
-		for _, envFrom := range container.EnvFrom {
-			if envFrom.SecretRef != nil {
-				result[envFrom.SecretRef.Name] = true
-			}
-		}
+	for i := range spec.EphemeralContainers {
+		walkEnv(spec.EphemeralContainers[i].Env, spec.EphemeralContainers[i].EnvFrom, fn)
+	}
+
+	for i := range spec.ImagePullSecrets {
+		fn(KindSecret, &spec.ImagePullSecrets[i].Name)
+	}
+}
+
+func walkVolume(vol *corev1.VolumeSource, fn WalkFunc) {
+	if vol.Secret != nil {
+		fn(KindSecret, &vol.Secret.SecretName)
+	}
+	if vol.ConfigMap != nil {
+		fn(KindConfigMap, &vol.ConfigMap.Name)
+	}
 
-		for _, envVar := range container.Env {
-			if envVar.ValueFrom != nil && envVar.ValueFrom.SecretKeyRef != nil {
-				result[envVar.ValueFrom.SecretKeyRef.Name] = true
+	if vol.Projected != nil {
+		for i := range vol.Projected.Sources {
+			source := &vol.Projected.Sources[i]
+			if source.Secret != nil {
+				fn(KindSecret, &source.Secret.Name)
+			}
+			if source.ConfigMap != nil {
+				fn(KindConfigMap, &source.ConfigMap.Name)
 			}
 		}
 	}
 
-	return result
+	if vol.CSI != nil && vol.CSI.NodePublishSecretRef != nil {
+		fn(KindSecret, &vol.CSI.NodePublishSecretRef.Name)
+	}
+	if vol.AzureFile != nil {
+		fn(KindSecret, &vol.AzureFile.SecretName)
+	}
+	if vol.CephFS != nil && vol.CephFS.SecretRef != nil {
+		fn(KindSecret, &vol.CephFS.SecretRef.Name)
+	}
+	if vol.Cinder != nil && vol.Cinder.SecretRef != nil {
+		fn(KindSecret, &vol.Cinder.SecretRef.Name)
+	}
+	if vol.FlexVolume != nil && vol.FlexVolume.SecretRef != nil {
+		fn(KindSecret, &vol.FlexVolume.SecretRef.Name)
+	}
+	if vol.ISCSI != nil && vol.ISCSI.SecretRef != nil {
+		fn(KindSecret, &vol.ISCSI.SecretRef.Name)
+	}
+	if vol.RBD != nil && vol.RBD.SecretRef != nil {
+		fn(KindSecret, &vol.RBD.SecretRef.Name)
+	}
+	if vol.ScaleIO != nil && vol.ScaleIO.SecretRef != nil {
+		fn(KindSecret, &vol.ScaleIO.SecretRef.Name)
+	}
+	if vol.StorageOS != nil && vol.StorageOS.SecretRef != nil {
+		fn(KindSecret, &vol.StorageOS.SecretRef.Name)
+	}
 }
 
-// GetConfMapRefFromPod returns a list of all names for ConfigMaps referenced by the object
-func GetConfMapRefFromPod(object corev1.PodSpec) map[string]bool {
-	result := map[string]bool{}
-
-	// Look at all volumes
-	for _, volume := range object.Volumes {
-		if volume.VolumeSource.ConfigMap != nil {
-			result[volume.VolumeSource.ConfigMap.Name] = true
+func walkEnv(env []corev1.EnvVar, envFrom []corev1.EnvFromSource, fn WalkFunc) {
+	for i := range envFrom {
+		if s := envFrom[i].SecretRef; s != nil {
+			fn(KindSecret, &s.Name)
+		}
+		if cm := envFrom[i].ConfigMapRef; cm != nil {
+			fn(KindConfigMap, &cm.Name)
 		}
 	}
 
-	// Look at all init containers
-	for _, container := range object.InitContainers {
-		for _, envFrom := range container.EnvFrom {
-			if envFrom.ConfigMapRef != nil {
-				result[envFrom.ConfigMapRef.Name] = true
-			}
+	for i := range env {
+		if env[i].ValueFrom == nil {
+			continue
 		}
-
-		for _, envVar := range container.Env {
-			if envVar.ValueFrom != nil && envVar.ValueFrom.ConfigMapKeyRef != nil {
-				result[envVar.ValueFrom.ConfigMapKeyRef.Name] = true
-			}
+		if sRef := env[i].ValueFrom.SecretKeyRef; sRef != nil {
+			fn(KindSecret, &sRef.Name)
+		}
+		if cmRef := env[i].ValueFrom.ConfigMapKeyRef; cmRef != nil {
+			fn(KindConfigMap, &cmRef.Name)
 		}
 	}
+}
 
-	// Look at all containers
-	for _, container := range object.Containers {
-		for _, envFrom := range container.EnvFrom {
-			if envFrom.ConfigMapRef != nil {
-				result[envFrom.ConfigMapRef.Name] = true
-			}
+// GetSecretRefFromPodSpec returns a list of all names for Secrets referenced by the object
+func GetSecretRefFromPodSpec(object corev1.PodSpec) map[string]bool {
+	result := map[string]bool{}
+
+	Walk(&object, func(kind Kind, name *string) {
+		if kind == KindSecret {
+			result[*name] = true
 		}
+	})
 
-		for _, envVar := range container.Env {
-			if envVar.ValueFrom != nil && envVar.ValueFrom.ConfigMapKeyRef != nil {
-				result[envVar.ValueFrom.ConfigMapKeyRef.Name] = true
-			}
+	return result
+}
+
+// GetConfMapRefFromPod returns a list of all names for ConfigMaps referenced by the object
+func GetConfMapRefFromPod(object corev1.PodSpec) map[string]bool {
+	result := map[string]bool{}
+
+	Walk(&object, func(kind Kind, name *string) {
+		if kind == KindConfigMap {
+			result[*name] = true
 		}
-	}
+	})
 
 	return result
 }
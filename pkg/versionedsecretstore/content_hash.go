@@ -0,0 +1,113 @@
+package versionedsecretstore
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"code.cloudfoundry.org/quarks-utils/pkg/names"
+)
+
+var (
+	// AnnotationContentHash is the annotation key for the hash of a versioned secret's data
+	AnnotationContentHash = fmt.Sprintf("%s/content-hash", names.GroupName)
+	// AnnotationPodTemplateContentHash is the pod template annotation key for the combined hash of all referenced versioned secrets
+	AnnotationPodTemplateContentHash = fmt.Sprintf("%s/versioned-secrets-hash", names.GroupName)
+)
+
+// ContentHash returns a stable, hex encoded sha256 hash of the secret data,
+// independent of the order of the keys.
+// Encrypted versions store a keyed HMAC instead, so the hash does not reveal
+// the data.
+func ContentHash(data map[string][]byte) string {
+	h := sha256.New()
+	writeData(h, data)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// SecretContentHash returns the content hash of the secret. It uses the
+// stored annotation if present and falls back to hashing the data.
+func SecretContentHash(secret corev1.Secret) string {
+	if h, ok := secret.Annotations[AnnotationContentHash]; ok && h != "" {
+		return h
+	}
+	return ContentHash(secret.Data)
+}
+
+// ConfigMapDataHash returns the content hash of config map data and binary
+// data. Kubernetes requires the keys of both to be distinct.
+func ConfigMapDataHash(data map[string]string, binaryData map[string][]byte) string {
+	merged := make(map[string][]byte, len(data)+len(binaryData))
+	for k, v := range data {
+		merged[k] = []byte(v)
+	}
+	for k, v := range binaryData {
+		merged[k] = v
+	}
+	return ContentHash(merged)
+}
+
+// ConfigMapContentHash returns the content hash of the config map. It uses
+// the stored annotation if present and falls back to hashing the data.
+func ConfigMapContentHash(configMap corev1.ConfigMap) string {
+	if h, ok := configMap.Annotations[AnnotationContentHash]; ok && h != "" {
+		return h
+	}
+	return ConfigMapDataHash(configMap.Data, configMap.BinaryData)
+}
+
+// PodTemplateContentHash combines the content hashes of the given secrets
+// into a single value. Use it as the value of the
+// AnnotationPodTemplateContentHash pod template annotation, so workloads only
+// roll if the content of a secret changes, not when just a new version with
+// identical data is referenced.
+func PodTemplateContentHash(secrets ...corev1.Secret) string {
+	hashes := map[string]string{}
+	for _, secret := range secrets {
+		name := NamePrefix(secret.Name)
+		if name == "" {
+			name = secret.Name
+		}
+		hashes[name] = SecretContentHash(secret)
+	}
+
+	keys := make([]string, 0, len(hashes))
+	for k := range hashes {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	h := sha256.New()
+	for _, k := range keys {
+		writeField(h, []byte(k))
+		writeField(h, []byte(hashes[k]))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// writeData writes the data sorted by key
+func writeData(h hash.Hash, data map[string][]byte) {
+	keys := make([]string, 0, len(data))
+	for k := range data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		writeField(h, []byte(k))
+		writeField(h, data[k])
+	}
+}
+
+// writeField writes a length prefixed field, so concatenated fields can't collide
+func writeField(h hash.Hash, b []byte) {
+	l := make([]byte, 8)
+	binary.BigEndian.PutUint64(l, uint64(len(b)))
+	h.Write(l)
+	h.Write(b)
+}
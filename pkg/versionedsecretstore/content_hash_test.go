@@ -0,0 +1,46 @@
+package versionedsecretstore_test
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "code.cloudfoundry.org/quarks-utils/pkg/versionedsecretstore"
+)
+
+var _ = Describe("ContentHash", func() {
+	secret := func(name string, data map[string][]byte) corev1.Secret {
+		return corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Data:       data,
+		}
+	}
+
+	It("is stable and depends on keys and values", func() {
+		a := ContentHash(map[string][]byte{"a": []byte("1"), "b": []byte("2")})
+		Expect(ContentHash(map[string][]byte{"b": []byte("2"), "a": []byte("1")})).To(Equal(a))
+		Expect(ContentHash(map[string][]byte{"a": []byte("12")})).ToNot(Equal(ContentHash(map[string][]byte{"a1": []byte("2")})))
+		Expect(ContentHash(map[string][]byte{"a": []byte("1"), "b": []byte("3")})).ToNot(Equal(a))
+	})
+
+	It("prefers the stored annotation", func() {
+		s := secret("foo-v1", map[string][]byte{"a": []byte("1")})
+		Expect(SecretContentHash(s)).To(Equal(ContentHash(s.Data)))
+
+		s.Annotations = map[string]string{AnnotationContentHash: "stored"}
+		Expect(SecretContentHash(s)).To(Equal("stored"))
+	})
+
+	It("ignores versions and order for the pod template hash", func() {
+		foo1 := secret("foo-v1", map[string][]byte{"a": []byte("1")})
+		foo2 := secret("foo-v2", map[string][]byte{"a": []byte("1")})
+		bar1 := secret("bar-v1", map[string][]byte{"b": []byte("1")})
+
+		Expect(PodTemplateContentHash(foo1, bar1)).To(Equal(PodTemplateContentHash(bar1, foo2)))
+
+		foo3 := secret("foo-v3", map[string][]byte{"a": []byte("2")})
+		Expect(PodTemplateContentHash(foo3, bar1)).ToNot(Equal(PodTemplateContentHash(foo1, bar1)))
+	})
+})
@@ -0,0 +1,83 @@
+package versionedsecretstore
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// CreateOptions are the parameters for creating a new version of a versioned
+// secret or config map
+type CreateOptions struct {
+	// Namespace of the versioned secret
+	Namespace string
+	// Name of the versioned secret, without the version suffix
+	Name string
+	// Data contains the binary data of the secret
+	Data map[string][]byte
+	// StringData contains string data, it is merged into Data and takes
+	// precedence, just like the StringData field of a Kubernetes secret
+	StringData map[string]string
+	// Type of the secret, e.g. `kubernetes.io/tls`, defaults to `Opaque`
+	Type corev1.SecretType
+	// Labels are added to each version, in addition to the version labels
+	Labels map[string]string
+	// Annotations are added to each version
+	Annotations map[string]string
+	// Owners of the version, see NewOwnerReference
+	Owners []metav1.OwnerReference
+	// SourceDescription explains the sources of the rendered secret
+	SourceDescription string
+}
+
+// data merges Data and StringData
+func (o CreateOptions) data() map[string][]byte {
+	data := make(map[string][]byte, len(o.Data)+len(o.StringData))
+	for k, v := range o.Data {
+		data[k] = v
+	}
+	for k, v := range o.StringData {
+		data[k] = []byte(v)
+	}
+	return data
+}
+
+// metadata returns copies of the labels and annotations, with the source
+// description and the name prefix added
+func (o CreateOptions) metadata() (map[string]string, map[string]string) {
+	labels := copyMap(o.Labels)
+	annotations := copyMap(o.Annotations)
+	annotations[AnnotationSourceDescription] = o.SourceDescription
+	setNamePrefix(o.Name, labels, annotations)
+	return labels, annotations
+}
+
+func (o CreateOptions) secretType() corev1.SecretType {
+	return normalizeType(o.Type)
+}
+
+// normalizeType returns the type the API server defaults to, for empty types
+func normalizeType(t corev1.SecretType) corev1.SecretType {
+	if t == "" {
+		return corev1.SecretTypeOpaque
+	}
+	return t
+}
+
+func copyMap(m map[string]string) map[string]string {
+	result := make(map[string]string, len(m))
+	for k, v := range m {
+		result[k] = v
+	}
+	return result
+}
+
+func copyBinaryMap(m map[string][]byte) map[string][]byte {
+	if len(m) == 0 {
+		return nil
+	}
+	result := make(map[string][]byte, len(m))
+	for k, v := range m {
+		result[k] = v
+	}
+	return result
+}
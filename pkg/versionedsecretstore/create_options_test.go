@@ -0,0 +1,74 @@
+package versionedsecretstore_test
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "code.cloudfoundry.org/quarks-utils/pkg/versionedsecretstore"
+	"code.cloudfoundry.org/quarks-utils/testing"
+)
+
+var _ = Describe("CreateWithOptions", func() {
+	var (
+		clientset *fake.Clientset
+		store     VersionedSecretStore
+		ctx       context.Context
+		opts      CreateOptions
+	)
+
+	BeforeEach(func() {
+		clientset = fake.NewSimpleClientset()
+		store = NewClientsetVersionedSecretStore(clientset)
+		ctx = testing.NewContext()
+
+		opts = CreateOptions{
+			Namespace:         "default",
+			Name:              "fake-keystore",
+			Data:              map[string][]byte{"keystore.p12": {0x30, 0x82, 0x00, 0xff}},
+			StringData:        map[string]string{"password": "foo"},
+			Labels:            map[string]string{"app": "java"},
+			SourceDescription: "created by a unit-test",
+		}
+	})
+
+	It("creates a version with binary and string data", func() {
+		Expect(store.CreateWithOptions(ctx, opts)).To(Succeed())
+
+		secret, err := clientset.CoreV1().Secrets("default").Get(ctx, "fake-keystore-v1", metav1.GetOptions{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(secret.Type).To(Equal(corev1.SecretTypeOpaque))
+		Expect(secret.Data).To(HaveKeyWithValue("keystore.p12", []byte{0x30, 0x82, 0x00, 0xff}))
+		Expect(secret.Data).To(HaveKeyWithValue("password", []byte("foo")))
+		Expect(secret.Labels).To(HaveKeyWithValue("app", "java"))
+		Expect(secret.Labels).To(HaveKeyWithValue(LabelVersion, "1"))
+		Expect(opts.Labels).ToNot(HaveKey(LabelVersion))
+	})
+
+	It("lets string data take precedence", func() {
+		opts.Data["password"] = []byte("bar")
+		Expect(store.CreateWithOptions(ctx, opts)).To(Succeed())
+
+		secret, err := store.Latest(ctx, "default", "fake-keystore")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(secret.Data["password"])).To(Equal("foo"))
+	})
+
+	It("creates a new version if only the type changes", func() {
+		Expect(store.CreateWithOptions(ctx, opts)).To(Succeed())
+		Expect(IsSecretIdenticalError(store.CreateWithOptions(ctx, opts))).To(BeTrue())
+
+		opts.Type = corev1.SecretTypeTLS
+		Expect(store.CreateWithOptions(ctx, opts)).To(Succeed())
+
+		secret, err := store.Latest(ctx, "default", "fake-keystore")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(secret.Name).To(Equal("fake-keystore-v2"))
+		Expect(secret.Type).To(Equal(corev1.SecretTypeTLS))
+	})
+})
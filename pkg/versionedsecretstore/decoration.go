@@ -0,0 +1,130 @@
+package versionedsecretstore
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"code.cloudfoundry.org/quarks-utils/pkg/names"
+)
+
+// Decoration is a status, which is recorded on a specific version of a versioned secret
+type Decoration string
+
+const (
+	// DecorationValidated marks a version, which passed validation
+	DecorationValidated Decoration = "validated"
+	// DecorationDeployed marks a version, which was deployed successfully
+	DecorationDeployed Decoration = "deployed"
+	// DecorationFailed marks a version, which failed validation or deployment
+	DecorationFailed Decoration = "failed"
+)
+
+var (
+	// DecorationPrefix is the prefix of the label and annotation keys for decorations.
+	// The label `<prefix>/<decoration>: "true"` allows selecting decorated
+	// versions, the annotation with the same key records time and actor.
+	DecorationPrefix = fmt.Sprintf("decoration.%s", names.GroupName)
+)
+
+// DecorationRecord describes when and by whom a version was decorated
+type DecorationRecord struct {
+	Decoration Decoration  `json:"-"`
+	Version    int         `json:"-"`
+	Time       metav1.Time `json:"time"`
+	Actor      string      `json:"actor"`
+}
+
+// Key returns the label and annotation key for the decoration
+func (d Decoration) Key() string {
+	return DecorationPrefix + "/" + string(d)
+}
+
+func (d Decoration) validate() error {
+	if errs := validation.IsQualifiedName(d.Key()); len(errs) > 0 {
+		return errors.Errorf("invalid decoration '%s': %s", d, strings.Join(errs, ", "))
+	}
+	return nil
+}
+
+// isDecorationKey returns true for label and annotation keys of decorations
+func isDecorationKey(key string) bool {
+	return strings.HasPrefix(key, DecorationPrefix+"/")
+}
+
+// Decorations returns the decorations of a version, ordered by time
+func Decorations(secret corev1.Secret) ([]DecorationRecord, error) {
+	return objectDecorations(&secret)
+}
+
+// ConfigMapDecorations returns the decorations of a config map version, ordered by time
+func ConfigMapDecorations(configMap corev1.ConfigMap) ([]DecorationRecord, error) {
+	return objectDecorations(&configMap)
+}
+
+func objectDecorations(object metav1.Object) ([]DecorationRecord, error) {
+	version, err := objectVersion(object)
+	if err != nil {
+		return nil, err
+	}
+
+	records := []DecorationRecord{}
+	for k, v := range object.GetAnnotations() {
+		if !isDecorationKey(k) {
+			continue
+		}
+
+		record := DecorationRecord{}
+		if err := json.Unmarshal([]byte(v), &record); err != nil {
+			return nil, errors.Wrapf(err, "invalid decoration annotation '%s' on version '%s/%s'", k, object.GetNamespace(), object.GetName())
+		}
+		record.Decoration = Decoration(strings.TrimPrefix(k, DecorationPrefix+"/"))
+		record.Version = version
+		records = append(records, record)
+	}
+
+	sortRecords(records)
+	return records, nil
+}
+
+// DecorateVersion records the decoration on a specific version of the
+// secret, together with the current time and the actor. Decorating the same
+// version again updates time and actor. It fails if the version does not
+// exist, so it does not race with the creation of new versions.
+func (p VersionedSecretImpl) DecorateVersion(ctx context.Context, namespace string, secretName string, version int, decoration Decoration, actor string) error {
+	return p.versions.decorateVersion(ctx, namespace, secretName, version, decoration, actor)
+}
+
+// LatestDecorated returns the latest version of the secret, which carries
+// the decoration. It returns a NotFound error if no version is decorated.
+func (p VersionedSecretImpl) LatestDecorated(ctx context.Context, namespace string, secretName string, decoration Decoration) (*corev1.Secret, error) {
+	object, err := p.versions.latestDecorated(ctx, namespace, secretName, decoration)
+	if err != nil {
+		return nil, err
+	}
+	return p.decrypted(ctx, object)
+}
+
+// DecorationHistory returns the decorations of all versions of the secret, ordered by time
+func (p VersionedSecretImpl) DecorationHistory(ctx context.Context, namespace string, secretName string) ([]DecorationRecord, error) {
+	return p.versions.decorationHistory(ctx, namespace, secretName)
+}
+
+func sortRecords(records []DecorationRecord) {
+	sort.SliceStable(records, func(i, j int) bool {
+		if !records[i].Time.Equal(&records[j].Time) {
+			return records[i].Time.Before(&records[j].Time)
+		}
+		if records[i].Version != records[j].Version {
+			return records[i].Version < records[j].Version
+		}
+		return records[i].Decoration < records[j].Decoration
+	})
+}
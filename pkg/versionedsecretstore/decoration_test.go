@@ -0,0 +1,105 @@
+package versionedsecretstore_test
+
+import (
+	"context"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/kubernetes/fake"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "code.cloudfoundry.org/quarks-utils/pkg/versionedsecretstore"
+	"code.cloudfoundry.org/quarks-utils/testing"
+)
+
+var _ = Describe("Decorations", func() {
+	var (
+		store VersionedSecretStore
+		ctx   context.Context
+	)
+
+	create := func(password string) error {
+		return store.CreateWithOptions(ctx, CreateOptions{
+			Namespace:         "default",
+			Name:              "fake-secret",
+			StringData:        map[string]string{"password": password},
+			SourceDescription: "created by a unit-test",
+		})
+	}
+
+	BeforeEach(func() {
+		store = NewClientsetVersionedSecretStore(fake.NewSimpleClientset())
+		ctx = testing.NewContext()
+
+		Expect(create("foo")).To(Succeed())
+		Expect(create("bar")).To(Succeed())
+	})
+
+	It("records time and actor on a specific version", func() {
+		Expect(store.DecorateVersion(ctx, "default", "fake-secret", 1, DecorationValidated, "validator")).To(Succeed())
+
+		secret, err := store.Get(ctx, "default", "fake-secret", 1)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(secret.Labels).To(HaveKeyWithValue(DecorationValidated.Key(), "true"))
+
+		records, err := Decorations(*secret)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(records).To(HaveLen(1))
+		Expect(records[0].Decoration).To(Equal(DecorationValidated))
+		Expect(records[0].Version).To(Equal(1))
+		Expect(records[0].Actor).To(Equal("validator"))
+		Expect(records[0].Time.IsZero()).To(BeFalse())
+
+		latest, err := store.Latest(ctx, "default", "fake-secret")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(latest.Labels).ToNot(HaveKey(DecorationValidated.Key()))
+	})
+
+	It("fails for versions which do not exist", func() {
+		Expect(store.DecorateVersion(ctx, "default", "fake-secret", 3, DecorationDeployed, "deployer")).ToNot(Succeed())
+	})
+
+	It("fails for invalid decorations", func() {
+		err := store.DecorateVersion(ctx, "default", "fake-secret", 1, Decoration("not valid"), "deployer")
+		Expect(err).To(MatchError(ContainSubstring("invalid decoration")))
+	})
+
+	It("finds the latest version with a decoration", func() {
+		Expect(store.DecorateVersion(ctx, "default", "fake-secret", 1, DecorationDeployed, "deployer")).To(Succeed())
+		Expect(store.DecorateVersion(ctx, "default", "fake-secret", 2, DecorationFailed, "deployer")).To(Succeed())
+
+		secret, err := store.LatestDecorated(ctx, "default", "fake-secret", DecorationDeployed)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(secret.Name).To(Equal("fake-secret-v1"))
+
+		_, err = store.LatestDecorated(ctx, "default", "fake-secret", DecorationValidated)
+		Expect(apierrors.IsNotFound(err)).To(BeTrue())
+	})
+
+	It("returns the decoration history of all versions", func() {
+		Expect(store.DecorateVersion(ctx, "default", "fake-secret", 1, DecorationValidated, "validator")).To(Succeed())
+		Expect(store.DecorateVersion(ctx, "default", "fake-secret", 1, DecorationDeployed, "deployer")).To(Succeed())
+		Expect(store.DecorateVersion(ctx, "default", "fake-secret", 2, DecorationFailed, "deployer")).To(Succeed())
+
+		history, err := store.DecorationHistory(ctx, "default", "fake-secret")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(history).To(HaveLen(3))
+		Expect(history[len(history)-1].Time.Before(&history[0].Time)).To(BeFalse())
+	})
+
+	It("does not create a new version because of decorations", func() {
+		Expect(store.DecorateVersion(ctx, "default", "fake-secret", 2, DecorationDeployed, "deployer")).To(Succeed())
+		Expect(IsSecretIdenticalError(create("bar"))).To(BeTrue())
+	})
+
+	It("does not copy decorations when rolling back", func() {
+		Expect(store.DecorateVersion(ctx, "default", "fake-secret", 1, DecorationFailed, "deployer")).To(Succeed())
+		Expect(store.Rollback(ctx, "default", "fake-secret", 1)).To(Succeed())
+
+		latest, err := store.Latest(ctx, "default", "fake-secret")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(latest.Labels).ToNot(HaveKey(DecorationFailed.Key()))
+		Expect(latest.Annotations).ToNot(HaveKey(DecorationFailed.Key()))
+	})
+})
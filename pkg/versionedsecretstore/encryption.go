@@ -0,0 +1,410 @@
+package versionedsecretstore
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/afero"
+	"golang.org/x/net/context"
+	"gopkg.in/yaml.v2"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"code.cloudfoundry.org/quarks-utils/pkg/names"
+)
+
+var (
+	// AnnotationEncryptionKey is the annotation key for the wrapped data
+	// encryption key of an encrypted versioned secret, the value has the
+	// format `<key encryption key id>:<base64 wrapped key>`
+	AnnotationEncryptionKey = fmt.Sprintf("%s/encryption-key", names.GroupName)
+)
+
+const (
+	// KeySetSecretKey is the key in the secret data of a secret key source, which contains the key set
+	KeySetSecretKey = "keys.yml"
+
+	keySize = 32
+
+	contentHashKeyLabel = "versioned secret content hash"
+)
+
+// KeySet contains the key encryption keys for envelope encryption.
+// Keys are base64 encoded and must be 32 bytes long (AES-256). New versions
+// are encrypted with the primary key, the other keys are kept to decrypt
+// existing versions until they are rotated.
+//
+// Example:
+//
+//	primary: key-2
+//	keys:
+//	  key-1: 3q2+7wAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA=
+//	  key-2: yv66vgAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA=
+type KeySet struct {
+	Primary string            `yaml:"primary"`
+	Keys    map[string]string `yaml:"keys"`
+}
+
+// KeySource provides the key encryption keys
+type KeySource interface {
+	KeySet(ctx context.Context) (KeySet, error)
+}
+
+type fileKeySource struct {
+	fs   afero.Fs
+	path string
+}
+
+// NewFileKeySource returns a KeySource, which reads the key set from a YAML file
+func NewFileKeySource(fs afero.Fs, path string) KeySource {
+	return &fileKeySource{fs: fs, path: path}
+}
+
+// KeySet reads the key set from the file
+func (s *fileKeySource) KeySet(_ context.Context) (KeySet, error) {
+	b, err := afero.ReadFile(s.fs, s.path)
+	if err != nil {
+		return KeySet{}, errors.Wrapf(err, "failed to read key set file '%s'", s.path)
+	}
+	return parseKeySet(b)
+}
+
+type secretKeySource struct {
+	backend versionedSecretStoreBackend
+	nn      types.NamespacedName
+}
+
+// NewSecretKeySource returns a KeySource, which reads the key set from the
+// KeySetSecretKey of a secret, using a controller-runtime client
+func NewSecretKeySource(client client.Client, nn types.NamespacedName) KeySource {
+	return &secretKeySource{backend: &versionedSecretStoreClientBackend{client: client}, nn: nn}
+}
+
+// NewClientsetSecretKeySource returns a KeySource, which reads the key set
+// from the KeySetSecretKey of a secret, using a kubernetes.Clientset
+func NewClientsetSecretKeySource(clientset kubernetes.Interface, nn types.NamespacedName) KeySource {
+	return &secretKeySource{backend: &versionedSecretStoreClientsetBackend{clientset: clientset}, nn: nn}
+}
+
+// KeySet reads the key set from the secret
+func (s *secretKeySource) KeySet(ctx context.Context) (KeySet, error) {
+	secret, err := s.backend.Get(ctx, s.nn)
+	if err != nil {
+		return KeySet{}, errors.Wrapf(err, "failed to get key set secret '%s'", s.nn)
+	}
+
+	b, ok := secret.Data[KeySetSecretKey]
+	if !ok {
+		return KeySet{}, errors.Errorf("key set secret '%s' has no '%s' key", s.nn, KeySetSecretKey)
+	}
+	return parseKeySet(b)
+}
+
+func parseKeySet(b []byte) (KeySet, error) {
+	keySet := KeySet{}
+	if err := yaml.Unmarshal(b, &keySet); err != nil {
+		return KeySet{}, errors.Wrap(err, "failed to parse key set")
+	}
+
+	if _, ok := keySet.Keys[keySet.Primary]; !ok {
+		return KeySet{}, errors.Errorf("primary key '%s' is missing from key set", keySet.Primary)
+	}
+	return keySet, nil
+}
+
+// key returns the decoded key for the id
+func (k KeySet) key(id string) ([]byte, error) {
+	encoded, ok := k.Keys[id]
+	if !ok {
+		return nil, errors.Errorf("key '%s' is missing from key set", id)
+	}
+
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to decode key '%s'", id)
+	}
+	if len(key) != keySize {
+		return nil, errors.Errorf("key '%s' has %d bytes, expected %d", id, len(key), keySize)
+	}
+	return key, nil
+}
+
+// envelope encrypts secret data with a random data encryption key per
+// version, which is wrapped with a key encryption key from the key source
+type envelope struct {
+	source KeySource
+}
+
+// seal encrypts the data and returns the encrypted data and the values for
+// the AnnotationEncryptionKey and AnnotationContentHash annotations
+func (e envelope) seal(ctx context.Context, data map[string][]byte) (map[string][]byte, string, string, error) {
+	keySet, err := e.source.KeySet(ctx)
+	if err != nil {
+		return nil, "", "", err
+	}
+
+	dek := make([]byte, keySize)
+	if _, err := io.ReadFull(rand.Reader, dek); err != nil {
+		return nil, "", "", errors.Wrap(err, "failed to generate data encryption key")
+	}
+
+	wrapped, err := wrapKey(keySet, dek)
+	if err != nil {
+		return nil, "", "", err
+	}
+
+	encrypted := make(map[string][]byte, len(data))
+	for k, v := range data {
+		encrypted[k], err = encrypt(dek, v, []byte(k))
+		if err != nil {
+			return nil, "", "", errors.Wrapf(err, "failed to encrypt key '%s'", k)
+		}
+	}
+
+	return encrypted, wrapped, encryptedContentHash(dek, data), nil
+}
+
+// open decrypts the data with the data encryption key from the annotation
+func (e envelope) open(ctx context.Context, data map[string][]byte, wrapped string) (map[string][]byte, error) {
+	keySet, err := e.source.KeySet(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	dek, _, err := unwrapKey(keySet, wrapped)
+	if err != nil {
+		return nil, err
+	}
+
+	decrypted := make(map[string][]byte, len(data))
+	for k, v := range data {
+		decrypted[k], err = decrypt(dek, v, []byte(k))
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to decrypt key '%s'", k)
+		}
+	}
+
+	return decrypted, nil
+}
+
+// checkKey returns an error if the data encryption key can't be unwrapped with the key set
+func (e envelope) checkKey(ctx context.Context, wrapped string) error {
+	keySet, err := e.source.KeySet(ctx)
+	if err != nil {
+		return err
+	}
+
+	_, _, err = unwrapKey(keySet, wrapped)
+	return err
+}
+
+// rewrap wraps the data encryption key with the primary key. It returns
+// false if the key already is wrapped with the primary key.
+func (e envelope) rewrap(ctx context.Context, wrapped string) (string, bool, error) {
+	keySet, err := e.source.KeySet(ctx)
+	if err != nil {
+		return "", false, err
+	}
+
+	dek, id, err := unwrapKey(keySet, wrapped)
+	if err != nil {
+		return "", false, err
+	}
+	if id == keySet.Primary {
+		return wrapped, false, nil
+	}
+
+	rewrapped, err := wrapKey(keySet, dek)
+	return rewrapped, true, err
+}
+
+// identicalContent returns true if the data matches the content hash of the
+// latest version. The hash is keyed with the data encryption key of the
+// latest version, which is kept when keys are rotated, so identical data is
+// detected as long as the latest version can be decrypted.
+func (e envelope) identicalContent(ctx context.Context, latest corev1.Secret, data map[string][]byte) bool {
+	keySet, err := e.source.KeySet(ctx)
+	if err != nil {
+		return false
+	}
+
+	dek, _, err := unwrapKey(keySet, latest.Annotations[AnnotationEncryptionKey])
+	if err != nil {
+		return false
+	}
+
+	hash := encryptedContentHash(dek, data)
+	return hmac.Equal([]byte(hash), []byte(latest.Annotations[AnnotationContentHash]))
+}
+
+// encryptedContentHash returns the value of the AnnotationContentHash
+// annotation for encrypted data: an HMAC of the plaintext, keyed with a key
+// derived from the data encryption key of the version.
+// Unlike a plain hash, the HMAC does not allow to guess the data of the
+// secret by hashing candidates. It does not depend on the key encryption
+// keys, so it does not change when keys are rotated.
+func encryptedContentHash(dek []byte, data map[string][]byte) string {
+	derived := hmac.New(sha256.New, dek)
+	derived.Write([]byte(contentHashKeyLabel))
+
+	h := hmac.New(sha256.New, derived.Sum(nil))
+	writeData(h, data)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func wrapKey(keySet KeySet, dek []byte) (string, error) {
+	kek, err := keySet.key(keySet.Primary)
+	if err != nil {
+		return "", err
+	}
+
+	wrapped, err := encrypt(kek, dek, []byte(keySet.Primary))
+	if err != nil {
+		return "", errors.Wrap(err, "failed to wrap data encryption key")
+	}
+	return keySet.Primary + ":" + base64.StdEncoding.EncodeToString(wrapped), nil
+}
+
+func unwrapKey(keySet KeySet, wrapped string) ([]byte, string, error) {
+	n := strings.LastIndex(wrapped, ":")
+	if n < 1 {
+		return nil, "", errors.Errorf("invalid wrapped data encryption key")
+	}
+	id := wrapped[:n]
+
+	kek, err := keySet.key(id)
+	if err != nil {
+		return nil, "", err
+	}
+
+	ciphertext, err := base64.StdEncoding.DecodeString(wrapped[n+1:])
+	if err != nil {
+		return nil, "", errors.Wrap(err, "failed to decode wrapped data encryption key")
+	}
+
+	dek, err := decrypt(kek, ciphertext, []byte(id))
+	if err != nil {
+		return nil, "", errors.Wrapf(err, "failed to unwrap data encryption key with key '%s'", id)
+	}
+	return dek, id, nil
+}
+
+// encrypt uses AES-GCM and prepends the nonce to the ciphertext
+func encrypt(key []byte, plaintext []byte, additionalData []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+func decrypt(key []byte, ciphertext []byte, additionalData []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ciphertext) < gcm.NonceSize() {
+		return nil, errors.New("ciphertext too short")
+	}
+	nonce := ciphertext[:gcm.NonceSize()]
+	return gcm.Open(nil, nonce, ciphertext[gcm.NonceSize():], additionalData)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// WithEncryption returns a copy of the store, which encrypts the data of new
+// versions with envelope encryption, using the key encryption keys from the
+// key source
+func (p VersionedSecretImpl) WithEncryption(source KeySource) VersionedSecretImpl {
+	p.envelope = &envelope{source: source}
+	return p
+}
+
+// RotateKeys wraps the data encryption keys of all encrypted versions of the
+// secret with the current primary key. Only the AnnotationEncryptionKey
+// annotation is patched, the encrypted data and the content hash stay
+// untouched. After rotating, old key encryption keys can be removed from the
+// key set.
+func (p VersionedSecretImpl) RotateKeys(ctx context.Context, namespace string, secretName string) error {
+	if p.envelope == nil {
+		return errors.Errorf("can not rotate keys of versioned secret '%s/%s' without a key source", namespace, secretName)
+	}
+
+	list, err := p.listSecrets(ctx, namespace, secretName)
+	if err != nil {
+		return err
+	}
+
+	for i := range list {
+		wrapped, ok := list[i].Annotations[AnnotationEncryptionKey]
+		if !ok {
+			continue
+		}
+
+		rewrapped, changed, err := p.envelope.rewrap(ctx, wrapped)
+		if err != nil {
+			return errors.Wrapf(err, "failed to rotate key of versioned secret '%s/%s'", namespace, list[i].Name)
+		}
+		if !changed {
+			continue
+		}
+
+		patch, err := json.Marshal(map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"annotations": map[string]string{AnnotationEncryptionKey: rewrapped},
+			},
+		})
+		if err != nil {
+			return errors.Wrapf(err, "failed to build annotation patch for versioned secret '%s/%s'", namespace, list[i].Name)
+		}
+
+		if err := p.versions.backend.Patch(ctx, &list[i], patch); err != nil {
+			return errors.Wrapf(err, "failed to rotate key of versioned secret '%s/%s'", namespace, list[i].Name)
+		}
+	}
+
+	return nil
+}
+
+// decrypt replaces the data of an encrypted secret with the plaintext
+func (p VersionedSecretImpl) decrypt(ctx context.Context, secret *corev1.Secret) error {
+	wrapped, ok := secret.Annotations[AnnotationEncryptionKey]
+	if !ok {
+		return nil
+	}
+
+	if p.envelope == nil {
+		return errors.Errorf("versioned secret '%s/%s' is encrypted, but no key source is configured", secret.Namespace, secret.Name)
+	}
+
+	data, err := p.envelope.open(ctx, secret.Data, wrapped)
+	if err != nil {
+		return errors.Wrapf(err, "failed to decrypt versioned secret '%s/%s'", secret.Namespace, secret.Name)
+	}
+	secret.Data = data
+	return nil
+}
@@ -0,0 +1,168 @@
+package versionedsecretstore_test
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/afero"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "code.cloudfoundry.org/quarks-utils/pkg/versionedsecretstore"
+	"code.cloudfoundry.org/quarks-utils/testing"
+)
+
+var _ = Describe("Encryption", func() {
+	var (
+		clientset *fake.Clientset
+		fs        afero.Fs
+		store     VersionedSecretImpl
+		ctx       context.Context
+	)
+
+	key := func(b byte) string {
+		return base64.StdEncoding.EncodeToString([]byte(strings.Repeat(string(b), 32)))
+	}
+
+	writeKeySet := func(primary string, keys map[string]string) {
+		content := fmt.Sprintf("primary: %s\nkeys:\n", primary)
+		for id, k := range keys {
+			content += fmt.Sprintf("  %s: %s\n", id, k)
+		}
+		Expect(afero.WriteFile(fs, "/keys.yml", []byte(content), 0600)).To(Succeed())
+	}
+
+	create := func(password string) error {
+		return store.Create(
+			ctx,
+			"default",
+			"some-owner",
+			types.UID("d3d423b7-a57f-43b0-8305-79d484154e4f"),
+			"some-kind",
+			"fake-secret",
+			map[string]string{"password": password},
+			nil,
+			map[string]string{},
+			"created by a unit-test",
+		)
+	}
+
+	raw := func(name string) *corev1.Secret {
+		secret, err := clientset.CoreV1().Secrets("default").Get(ctx, name, metav1.GetOptions{})
+		Expect(err).ToNot(HaveOccurred())
+		return secret
+	}
+
+	BeforeEach(func() {
+		clientset = fake.NewSimpleClientset()
+		fs = afero.NewMemMapFs()
+		ctx = testing.NewContext()
+		writeKeySet("key-1", map[string]string{"key-1": key('a')})
+		store = NewClientsetVersionedSecretStore(clientset).WithEncryption(NewFileKeySource(fs, "/keys.yml"))
+	})
+
+	It("stores encrypted data and decrypts it transparently", func() {
+		Expect(create("secret-password")).To(Succeed())
+
+		secret := raw("fake-secret-v1")
+		Expect(secret.StringData).To(BeEmpty())
+		Expect(string(secret.Data["password"])).ToNot(ContainSubstring("secret-password"))
+		Expect(secret.Annotations[AnnotationEncryptionKey]).To(HavePrefix("key-1:"))
+
+		latest, err := store.Latest(ctx, "default", "fake-secret")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(latest.Data["password"])).To(Equal("secret-password"))
+
+		list, err := store.List(ctx, "default", "fake-secret")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(list[0].Data["password"])).To(Equal("secret-password"))
+	})
+
+	It("detects identical content", func() {
+		Expect(create("secret-password")).To(Succeed())
+		Expect(IsSecretIdenticalError(create("secret-password"))).To(BeTrue())
+	})
+
+	It("stores a keyed content hash", func() {
+		Expect(create("secret-password")).To(Succeed())
+
+		secret := raw("fake-secret-v1")
+		hash := secret.Annotations[AnnotationContentHash]
+		Expect(hash).ToNot(BeEmpty())
+		Expect(hash).ToNot(Equal(ContentHash(map[string][]byte{"password": []byte("secret-password")})))
+		Expect(SecretContentHash(*secret)).To(Equal(hash))
+	})
+
+	It("detects identical content after the primary key changed", func() {
+		Expect(create("secret-password")).To(Succeed())
+
+		writeKeySet("key-2", map[string]string{"key-1": key('a'), "key-2": key('b')})
+		Expect(IsSecretIdenticalError(create("secret-password"))).To(BeTrue())
+
+		Expect(create("other-password")).To(Succeed())
+		Expect(raw("fake-secret-v2").Annotations[AnnotationEncryptionKey]).To(HavePrefix("key-2:"))
+	})
+
+	It("detects identical content after rotating and removing the old key", func() {
+		Expect(create("secret-password")).To(Succeed())
+		hash := raw("fake-secret-v1").Annotations[AnnotationContentHash]
+
+		writeKeySet("key-2", map[string]string{"key-1": key('a'), "key-2": key('b')})
+		Expect(store.RotateKeys(ctx, "default", "fake-secret")).To(Succeed())
+		writeKeySet("key-2", map[string]string{"key-2": key('b')})
+
+		Expect(raw("fake-secret-v1").Annotations[AnnotationContentHash]).To(Equal(hash))
+		Expect(IsSecretIdenticalError(create("secret-password"))).To(BeTrue())
+
+		versions, err := store.VersionCount(ctx, "default", "fake-secret")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(versions).To(Equal(1))
+	})
+
+	It("fails to read encrypted versions without a key source", func() {
+		Expect(create("secret-password")).To(Succeed())
+
+		_, err := NewClientsetVersionedSecretStore(clientset).Latest(ctx, "default", "fake-secret")
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("no key source"))
+	})
+
+	It("rewraps existing versions when rotating keys", func() {
+		Expect(create("secret-password")).To(Succeed())
+		encrypted := raw("fake-secret-v1").Data["password"]
+
+		writeKeySet("key-2", map[string]string{"key-1": key('a'), "key-2": key('b')})
+		Expect(store.RotateKeys(ctx, "default", "fake-secret")).To(Succeed())
+
+		secret := raw("fake-secret-v1")
+		Expect(secret.Annotations[AnnotationEncryptionKey]).To(HavePrefix("key-2:"))
+		Expect(secret.Data["password"]).To(Equal(encrypted))
+
+		writeKeySet("key-2", map[string]string{"key-2": key('b')})
+		latest, err := store.Latest(ctx, "default", "fake-secret")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(latest.Data["password"])).To(Equal("secret-password"))
+	})
+
+	It("reads the key set from a secret", func() {
+		_, err := clientset.CoreV1().Secrets("default").Create(ctx, &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "keys", Namespace: "default"},
+			Data: map[string][]byte{
+				KeySetSecretKey: []byte(fmt.Sprintf("primary: key-1\nkeys:\n  key-1: %s\n", key('c'))),
+			},
+		}, metav1.CreateOptions{})
+		Expect(err).ToNot(HaveOccurred())
+
+		source := NewClientsetSecretKeySource(clientset, types.NamespacedName{Namespace: "default", Name: "keys"})
+		keySet, err := source.KeySet(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(keySet.Primary).To(Equal("key-1"))
+	})
+})
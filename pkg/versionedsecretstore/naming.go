@@ -0,0 +1,159 @@
+package versionedsecretstore
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"code.cloudfoundry.org/quarks-utils/pkg/names"
+)
+
+var (
+	// LabelNamePrefix is the label key for the name prefix of a version. The
+	// value is the prefix, or a shortened, unique form of it if the prefix is
+	// not a valid label value.
+	LabelNamePrefix = fmt.Sprintf("%s/name-prefix", names.GroupName)
+	// AnnotationNamePrefix is the annotation key for the name prefix of a
+	// version, it is only set if the prefix does not fit into LabelNamePrefix
+	AnnotationNamePrefix = fmt.Sprintf("%s/name-prefix", names.GroupName)
+
+	invalidNameChars = regexp.MustCompile(`[^a-z0-9.-]`)
+)
+
+const (
+	// maxVersionSuffixLength is the length of the longest version suffix, `-v` and up to 10 digits.
+	// Prefixes are shortened independently of the actual version, so all versions share a prefix.
+	maxVersionSuffixLength = 12
+)
+
+// versionedName returns the name of a version. Prefixes which are too long
+// for the name of a Kubernetes object are shortened and get a hash of the
+// prefix appended, so the name is stable and unique.
+func versionedName(namePrefix string, version int) string {
+	return fmt.Sprintf("%s-v%d", shortNamePrefix(namePrefix), version)
+}
+
+// generateVersionedName creates the name of a versioned secret or config map and errors if it's invalid
+func generateVersionedName(namePrefix string, version int) (string, error) {
+	if namePrefix == "" {
+		return "", errors.Errorf("versioned name prefix must not be empty")
+	}
+
+	// Check for Kubernetes name requirements (characters)
+	if invalidNameChars.MatchString(namePrefix) {
+		return "", errors.Errorf("versioned name contains invalid characters, only lower case, dot and dash are allowed")
+	}
+
+	return versionedName(namePrefix, version), nil
+}
+
+// versionedNameRegex matches the names of all versions for the name prefix
+func versionedNameRegex(namePrefix string) *regexp.Regexp {
+	return regexp.MustCompile(fmt.Sprintf(`^%s-v\d+$`, regexp.QuoteMeta(shortNamePrefix(namePrefix))))
+}
+
+// VolumeName returns a name for a pod volume, which mounts a version of a
+// versioned secret or config map. It is a valid DNS label of at most 63
+// characters, which is unique for the name prefix and version.
+func VolumeName(namePrefix string, version int) string {
+	prefix := strings.ReplaceAll(namePrefix, ".", "-")
+	prefix = shorten(namePrefix, prefix, validation.DNS1123LabelMaxLength-maxVersionSuffixLength)
+	return fmt.Sprintf("%s-v%d", prefix, version)
+}
+
+// UnversionedName returns the name prefix of a version. It prefers the
+// prefix recorded in the labels and annotations of the version over the
+// prefix in its name, which might have been shortened.
+func UnversionedName(object metav1.Object) string {
+	if prefix, ok := object.GetAnnotations()[AnnotationNamePrefix]; ok {
+		return prefix
+	}
+	if prefix, ok := object.GetLabels()[LabelNamePrefix]; ok {
+		return prefix
+	}
+	return NamePrefix(object.GetName())
+}
+
+// latestVersion returns the latest of the versions, which belong to the same
+// versioned secret or config map as the referenced version, and its
+// unversioned name. The unversioned name is read from the labels and
+// annotations of the referenced version, since the prefix in the name of the
+// version might be shortened. If the referenced version doesn't exist, the
+// versions are matched by the prefix of their names.
+func latestVersion(versions []metav1.Object, referenced string) (metav1.Object, string) {
+	name := NamePrefix(referenced)
+	matches := func(v metav1.Object) bool { return NamePrefix(v.GetName()) == name }
+	for _, v := range versions {
+		if v.GetName() == referenced {
+			name = UnversionedName(v)
+			matches = func(v metav1.Object) bool { return UnversionedName(v) == name }
+			break
+		}
+	}
+
+	var latest metav1.Object
+	greatest := 0
+	for _, v := range versions {
+		if !matches(v) {
+			continue
+		}
+
+		version, err := versionFromLabels(v.GetName(), v.GetLabels())
+		if err != nil {
+			continue
+		}
+		if version > greatest {
+			greatest = version
+			latest = v
+		}
+	}
+
+	return latest, name
+}
+
+// setNamePrefix records the name prefix in the labels and, if it is not a
+// valid label value, in the annotations
+func setNamePrefix(namePrefix string, labels map[string]string, annotations map[string]string) {
+	value := namePrefix
+	if len(validation.IsValidLabelValue(namePrefix)) > 0 {
+		value = shorten(namePrefix, strings.Trim(namePrefix, "-."), validation.LabelValueMaxLength)
+		annotations[AnnotationNamePrefix] = namePrefix
+	}
+	labels[LabelNamePrefix] = value
+}
+
+func shortNamePrefix(namePrefix string) string {
+	return shorten(namePrefix, namePrefix, validation.DNS1123SubdomainMaxLength-maxVersionSuffixLength)
+}
+
+// shorten returns s, if it is the original string and not longer than
+// maxLen. Otherwise it truncates s and appends the md5 sum of the original,
+// so different originals never map to the same string.
+func shorten(original string, s string, maxLen int) string {
+	if s == original && len(s) <= maxLen {
+		return s
+	}
+
+	sum := md5.Sum([]byte(original))
+	hash := hex.EncodeToString(sum[:])
+
+	keep := maxLen - len(hash) - 1
+	if keep > len(s) {
+		keep = len(s)
+	}
+	if keep <= 0 {
+		return hash[:maxLen]
+	}
+
+	s = strings.TrimRight(s[:keep], "-.")
+	if s == "" {
+		return hash
+	}
+	return s + "-" + hash
+}
@@ -0,0 +1,159 @@
+package versionedsecretstore_test
+
+import (
+	"context"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/kubernetes/fake"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "code.cloudfoundry.org/quarks-utils/pkg/versionedsecretstore"
+	"code.cloudfoundry.org/quarks-utils/testing"
+)
+
+var _ = Describe("Naming", func() {
+	longPrefix := strings.Repeat("foo.bar", 40)
+
+	Describe("VersionedName", func() {
+		It("appends the version to short prefixes", func() {
+			Expect(VersionedName("fake-secret", 3)).To(Equal("fake-secret-v3"))
+		})
+
+		It("shortens long prefixes and keeps the version suffix", func() {
+			name := VersionedName(longPrefix, 12)
+			Expect(validation.IsDNS1123Subdomain(name)).To(BeEmpty())
+			Expect(name).To(HaveSuffix("-v12"))
+			Expect(VersionFromName(name)).To(Equal(12))
+		})
+
+		It("uses the same prefix for all versions", func() {
+			Expect(NamePrefix(VersionedName(longPrefix, 1))).To(Equal(NamePrefix(VersionedName(longPrefix, 1234567890))))
+		})
+
+		It("returns different names for different long prefixes", func() {
+			Expect(VersionedName(longPrefix+"a", 1)).ToNot(Equal(VersionedName(longPrefix+"b", 1)))
+		})
+
+		It("sanitizes invalid prefixes, which the store rejects", func() {
+			Expect(VersionedName("Fake_Secret", 1)).To(Equal("fake-secret-v1"))
+
+			store := NewClientsetVersionedSecretStore(fake.NewSimpleClientset())
+			err := store.CreateWithOptions(testing.NewContext(), CreateOptions{
+				Namespace:  "default",
+				Name:       "Fake_Secret",
+				StringData: map[string]string{"foo": "bar"},
+			})
+			Expect(err).To(MatchError(ContainSubstring("invalid characters")))
+		})
+	})
+
+	Describe("VolumeName", func() {
+		It("returns a valid DNS label", func() {
+			for _, prefix := range []string{"fake-secret", "fake.secret", longPrefix} {
+				name := VolumeName(prefix, 1234567890)
+				Expect(validation.IsDNS1123Label(name)).To(BeEmpty(), name)
+			}
+		})
+
+		It("keeps short prefixes without dots", func() {
+			Expect(VolumeName("fake-secret", 2)).To(Equal("fake-secret-v2"))
+		})
+
+		It("does not map different prefixes to the same name", func() {
+			Expect(VolumeName("fake.secret", 1)).ToNot(Equal(VolumeName("fake-secret", 1)))
+			Expect(VolumeName(longPrefix+"a", 1)).ToNot(Equal(VolumeName(longPrefix+"b", 1)))
+		})
+	})
+
+	Describe("UnversionedName", func() {
+		It("maps versions back to their prefix", func() {
+			ctx := testing.NewContext()
+			store := NewClientsetVersionedSecretStore(fake.NewSimpleClientset())
+
+			for _, prefix := range []string{"fake-secret", longPrefix} {
+				err := store.CreateWithOptions(ctx, CreateOptions{
+					Namespace:  "default",
+					Name:       prefix,
+					StringData: map[string]string{"password": "foo"},
+				})
+				Expect(err).ToNot(HaveOccurred())
+
+				secret, err := store.Latest(ctx, "default", prefix)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(secret.Labels).To(HaveKey(LabelNamePrefix))
+				Expect(UnversionedName(secret)).To(Equal(prefix))
+			}
+		})
+	})
+	Describe("SetSecretReferences", func() {
+		var (
+			store VersionedSecretImpl
+			ctx   context.Context
+		)
+
+		create := func(prefix string, password string) {
+			err := store.CreateWithOptions(ctx, CreateOptions{
+				Namespace:  "default",
+				Name:       prefix,
+				StringData: map[string]string{"password": password},
+			})
+			Expect(err).ToNot(HaveOccurred())
+		}
+
+		podSpec := func(secretName string) *corev1.PodSpec {
+			return &corev1.PodSpec{
+				Volumes: []corev1.Volume{
+					{
+						Name: "secret",
+						VolumeSource: corev1.VolumeSource{
+							Secret: &corev1.SecretVolumeSource{SecretName: secretName},
+						},
+					},
+				},
+			}
+		}
+
+		BeforeEach(func() {
+			ctx = testing.NewContext()
+			store = NewClientsetVersionedSecretStore(fake.NewSimpleClientset())
+		})
+
+		It("replaces references to versions with shortened names", func() {
+			create(longPrefix, "foo")
+			create(longPrefix, "bar")
+
+			spec := podSpec(VersionedName(longPrefix, 1))
+			Expect(store.SetSecretReferences(ctx, "default", spec)).To(Succeed())
+			Expect(spec.Volumes[0].Secret.SecretName).To(Equal(VersionedName(longPrefix, 2)))
+		})
+
+		It("distinguishes prefixes which look like versions", func() {
+			create("fake-secret", "foo")
+			create("fake-secret-v1", "foo")
+			create("fake-secret-v1", "bar")
+
+			spec := podSpec("fake-secret-v1")
+			Expect(store.SetSecretReferences(ctx, "default", spec)).To(Succeed())
+			Expect(spec.Volumes[0].Secret.SecretName).To(Equal("fake-secret-v1"))
+
+			spec = podSpec("fake-secret-v1-v1")
+			Expect(store.SetSecretReferences(ctx, "default", spec)).To(Succeed())
+			Expect(spec.Volumes[0].Secret.SecretName).To(Equal("fake-secret-v1-v2"))
+		})
+
+		It("replaces references to deleted versions", func() {
+			create(longPrefix, "foo")
+			create(longPrefix, "bar")
+			_, err := store.Prune(ctx, "default", longPrefix, 1)
+			Expect(err).ToNot(HaveOccurred())
+
+			spec := podSpec(VersionedName(longPrefix, 1))
+			Expect(store.SetSecretReferences(ctx, "default", spec)).To(Succeed())
+			Expect(spec.Volumes[0].Secret.SecretName).To(Equal(VersionedName(longPrefix, 2)))
+		})
+	})
+})
@@ -0,0 +1,68 @@
+package versionedsecretstore
+
+import (
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+
+	"code.cloudfoundry.org/quarks-utils/pkg/pointers"
+)
+
+// NewOwnerReference returns an owner reference for the object.
+// APIVersion and Kind are taken from the object's GVK. If the object has no
+// type meta, which is usual for typed objects read by a client, the GVK is
+// looked up in the scheme.
+func NewOwnerReference(owner client.Object, scheme *runtime.Scheme, controller bool) (metav1.OwnerReference, error) {
+	gvk := owner.GetObjectKind().GroupVersionKind()
+	if gvk.Empty() {
+		if scheme == nil {
+			return metav1.OwnerReference{}, errors.Errorf("owner '%s' has no GVK and no scheme was given", owner.GetName())
+		}
+
+		var err error
+		gvk, err = apiutil.GVKForObject(owner, scheme)
+		if err != nil {
+			return metav1.OwnerReference{}, errors.Wrapf(err, "failed to find GVK of owner '%s'", owner.GetName())
+		}
+	}
+
+	return metav1.OwnerReference{
+		APIVersion:         gvk.GroupVersion().String(),
+		Kind:               gvk.Kind,
+		Name:               owner.GetName(),
+		UID:                owner.GetUID(),
+		BlockOwnerDeletion: pointers.Bool(false),
+		Controller:         pointers.Bool(controller),
+	}, nil
+}
+
+// controllerOwnerReference returns a controller reference to an owner in
+// the quarks API group
+func controllerOwnerReference(name string, uid types.UID, kind string) metav1.OwnerReference {
+	return metav1.OwnerReference{
+		APIVersion:         LabelAPIVersion,
+		Kind:               kind,
+		Name:               name,
+		UID:                uid,
+		BlockOwnerDeletion: pointers.Bool(false),
+		Controller:         pointers.Bool(true),
+	}
+}
+
+// validateOwners checks that at most one owner reference is a controller
+func validateOwners(owners []metav1.OwnerReference) error {
+	controllers := 0
+	for _, owner := range owners {
+		if owner.Controller != nil && *owner.Controller {
+			controllers++
+		}
+	}
+
+	if controllers > 1 {
+		return errors.Errorf("only one owner can be a controller, got %d", controllers)
+	}
+	return nil
+}
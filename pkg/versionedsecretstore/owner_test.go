@@ -0,0 +1,90 @@
+package versionedsecretstore_test
+
+import (
+	"context"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/kubernetes/scheme"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"code.cloudfoundry.org/quarks-utils/pkg/pointers"
+	. "code.cloudfoundry.org/quarks-utils/pkg/versionedsecretstore"
+	"code.cloudfoundry.org/quarks-utils/testing"
+)
+
+var _ = Describe("Owners", func() {
+	var (
+		clientset   *fake.Clientset
+		store       VersionedSecretStore
+		ctx         context.Context
+		statefulSet *appsv1.StatefulSet
+		configMap   *corev1.ConfigMap
+	)
+
+	BeforeEach(func() {
+		clientset = fake.NewSimpleClientset()
+		store = NewClientsetVersionedSecretStore(clientset)
+		ctx = testing.NewContext()
+
+		statefulSet = &appsv1.StatefulSet{ObjectMeta: metav1.ObjectMeta{Name: "sts", UID: "sts-uid"}}
+		configMap = &corev1.ConfigMap{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+			ObjectMeta: metav1.ObjectMeta{Name: "cm", UID: "cm-uid"},
+		}
+	})
+
+	Describe("NewOwnerReference", func() {
+		It("looks up the GVK in the scheme", func() {
+			ref, err := NewOwnerReference(statefulSet, scheme.Scheme, true)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ref.APIVersion).To(Equal("apps/v1"))
+			Expect(ref.Kind).To(Equal("StatefulSet"))
+			Expect(ref.UID).To(BeEquivalentTo("sts-uid"))
+			Expect(*ref.Controller).To(BeTrue())
+		})
+
+		It("uses the type meta of the object", func() {
+			ref, err := NewOwnerReference(configMap, nil, false)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ref.APIVersion).To(Equal("v1"))
+			Expect(ref.Kind).To(Equal("ConfigMap"))
+			Expect(*ref.Controller).To(BeFalse())
+		})
+
+		It("fails without type meta and scheme", func() {
+			_, err := NewOwnerReference(statefulSet, nil, true)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("CreateWithOwners", func() {
+		It("references multiple owners", func() {
+			controller, err := NewOwnerReference(statefulSet, scheme.Scheme, true)
+			Expect(err).ToNot(HaveOccurred())
+			owner, err := NewOwnerReference(configMap, nil, false)
+			Expect(err).ToNot(HaveOccurred())
+
+			err = store.CreateWithOwners(ctx, "default", []metav1.OwnerReference{controller, owner}, "fake-secret", map[string]string{"foo": "bar"}, nil, map[string]string{}, "created by a unit-test")
+			Expect(err).ToNot(HaveOccurred())
+
+			secret, err := clientset.CoreV1().Secrets("default").Get(ctx, "fake-secret-v1", metav1.GetOptions{})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(secret.OwnerReferences).To(ConsistOf(controller, owner))
+		})
+
+		It("rejects multiple controllers", func() {
+			owners := []metav1.OwnerReference{
+				{APIVersion: "v1", Kind: "ConfigMap", Name: "a", UID: "a", Controller: pointers.Bool(true)},
+				{APIVersion: "v1", Kind: "ConfigMap", Name: "b", UID: "b", Controller: pointers.Bool(true)},
+			}
+			err := store.CreateWithOwners(ctx, "default", owners, "fake-secret", map[string]string{"foo": "bar"}, nil, map[string]string{}, "created by a unit-test")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("only one owner can be a controller"))
+		})
+	})
+})
@@ -0,0 +1,261 @@
+package versionedsecretstore
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"code.cloudfoundry.org/quarks-utils/pkg/ctxlog"
+	"code.cloudfoundry.org/quarks-utils/pkg/names"
+	"code.cloudfoundry.org/quarks-utils/pkg/pointers"
+)
+
+var (
+	// LabelReplicaSourceNamespace is the label key for the namespace a replicated versioned secret was copied from
+	LabelReplicaSourceNamespace = fmt.Sprintf("%s/replica-source-namespace", names.GroupName)
+)
+
+type replicatorBackend interface {
+	versionedSecretStoreBackend
+	ListNamespaces(ctx context.Context, matchLabels map[string]string) (*corev1.NamespaceList, error)
+}
+
+// Replicator mirrors the versions of a versioned secret from a source
+// namespace to all namespaces matching a label selector.
+//
+// Replicas keep the name, and therefore the version number, of their source
+// version. They are versioned secrets themselves, so the VersionedSecretStore
+// of the target namespace can be used to read them.
+// Replicas have no owner references, since those can't cross namespaces.
+// Instead they are labeled with LabelReplicaSourceNamespace.
+//
+// Encrypted versions are copied as they are, so the stores reading replicas
+// in the target namespaces need a key source, which contains the key
+// encryption keys of the source namespace. Use WithKeySource to check this
+// before replicating.
+// If a target namespace already has a version with the same name, which is
+// not a replica of the source, it is kept and the version is not replicated
+// to that namespace.
+//
+// The replicator does not watch the source. Use its Prune and Delete methods
+// instead of the ones of the VersionedSecretStore to remove versions of a
+// replicated secret, or call Replicate after each change to the source.
+type Replicator struct {
+	store    VersionedSecretImpl
+	backend  replicatorBackend
+	envelope *envelope
+}
+
+// NewReplicator returns a Replicator using a controller-runtime client backend
+func NewReplicator(client client.Client) Replicator {
+	backend := &versionedSecretStoreClientBackend{client: client}
+	return Replicator{
+		store:   newVersionedSecretImpl(backend),
+		backend: backend,
+	}
+}
+
+// NewClientsetReplicator returns a Replicator using a kubernetes.Clientset backend
+func NewClientsetReplicator(clientset kubernetes.Interface) Replicator {
+	backend := &versionedSecretStoreClientsetBackend{clientset: clientset}
+	return Replicator{
+		store:   newVersionedSecretImpl(backend),
+		backend: backend,
+	}
+}
+
+// WithKeySource returns a copy of the replicator, which checks that the key
+// source can unwrap the data encryption keys of encrypted versions before
+// replicating them. It should be the key source of the stores in the target
+// namespaces.
+func (r Replicator) WithKeySource(source KeySource) Replicator {
+	r.envelope = &envelope{source: source}
+	return r
+}
+
+// Replicate mirrors all versions of the secret in the source namespace to
+// the namespaces matching namespaceLabels.
+// Missing versions are created, replicas of versions which no longer exist in
+// the source namespace and replicas in namespaces which no longer match are
+// deleted. If the source secret was deleted, all replicas are removed.
+//
+// Replicate is not triggered by the store. Callers have to invoke it after
+// creating versions in the source namespace and whenever the namespaces
+// matching namespaceLabels change, e.g. from a reconciler watching both.
+func (r Replicator) Replicate(ctx context.Context, sourceNamespace string, secretName string, namespaceLabels map[string]string) error {
+	// Encrypted versions are copied as they are, without decrypting them
+	sources, err := r.store.listSecrets(ctx, sourceNamespace, secretName)
+	if err != nil {
+		return errors.Wrapf(err, "failed to list versions of secret '%s/%s'", sourceNamespace, secretName)
+	}
+
+	if r.envelope != nil {
+		for _, source := range sources {
+			wrapped, ok := source.Annotations[AnnotationEncryptionKey]
+			if !ok {
+				continue
+			}
+			if err := r.envelope.checkKey(ctx, wrapped); err != nil {
+				return errors.Wrapf(err, "can't replicate encrypted versioned secret '%s/%s', the key source of the target namespaces can't decrypt it", sourceNamespace, source.Name)
+			}
+		}
+	}
+
+	namespaces, err := r.backend.ListNamespaces(ctx, namespaceLabels)
+	if err != nil {
+		return errors.Wrapf(err, "failed to list namespaces for replicating secret '%s/%s'", sourceNamespace, secretName)
+	}
+
+	replicas, err := r.listReplicas(ctx, sourceNamespace, secretName)
+	if err != nil {
+		return err
+	}
+
+	existing := map[string]struct{}{}
+	for _, replica := range replicas {
+		existing[replica.Namespace+"/"+replica.Name] = struct{}{}
+	}
+
+	wanted := map[string]struct{}{}
+	for _, ns := range namespaces.Items {
+		if ns.Name == sourceNamespace {
+			continue
+		}
+
+		for _, source := range sources {
+			key := ns.Name + "/" + source.Name
+			wanted[key] = struct{}{}
+			if _, ok := existing[key]; ok {
+				continue
+			}
+
+			ctxlog.Debugf(ctx, "Replicating versioned secret '%s/%s' to namespace '%s'", sourceNamespace, source.Name, ns.Name)
+			err := r.backend.Create(ctx, replica(source, ns.Name))
+			if apierrors.IsAlreadyExists(err) {
+				ctxlog.Infof(ctx, "Not replicating versioned secret '%s/%s', namespace '%s' has its own version with the same name", sourceNamespace, source.Name, ns.Name)
+				continue
+			}
+			if err != nil {
+				return errors.Wrapf(err, "failed to replicate versioned secret '%s/%s' to namespace '%s'", sourceNamespace, source.Name, ns.Name)
+			}
+		}
+	}
+
+	for i := range replicas {
+		if _, ok := wanted[replicas[i].Namespace+"/"+replicas[i].Name]; ok {
+			continue
+		}
+
+		ctxlog.Debugf(ctx, "Deleting replica '%s/%s' of versioned secret from namespace '%s'", replicas[i].Namespace, replicas[i].Name, sourceNamespace)
+		if err := r.backend.Delete(ctx, &replicas[i]); err != nil {
+			return errors.Wrapf(err, "failed to delete replica '%s/%s'", replicas[i].Namespace, replicas[i].Name)
+		}
+	}
+
+	return nil
+}
+
+// DeleteReplicas removes all replicas of the secret from all namespaces
+func (r Replicator) DeleteReplicas(ctx context.Context, sourceNamespace string, secretName string) error {
+	replicas, err := r.listReplicas(ctx, sourceNamespace, secretName)
+	if err != nil {
+		return err
+	}
+
+	for i := range replicas {
+		if err := r.backend.Delete(ctx, &replicas[i]); err != nil {
+			return errors.Wrapf(err, "failed to delete replica '%s/%s'", replicas[i].Namespace, replicas[i].Name)
+		}
+	}
+
+	return nil
+}
+
+// Prune removes all but the newest keep versions of the secret in the source
+// namespace, like VersionedSecretStore.Prune, together with their replicas.
+// It returns the names of the deleted versions.
+func (r Replicator) Prune(ctx context.Context, sourceNamespace string, secretName string, keep int) ([]string, error) {
+	deleted, err := r.store.Prune(ctx, sourceNamespace, secretName, keep)
+	if err != nil {
+		return deleted, err
+	}
+
+	replicas, err := r.listReplicas(ctx, sourceNamespace, secretName)
+	if err != nil {
+		return deleted, err
+	}
+
+	pruned := map[string]struct{}{}
+	for _, name := range deleted {
+		pruned[name] = struct{}{}
+	}
+
+	for i := range replicas {
+		if _, ok := pruned[replicas[i].Name]; !ok {
+			continue
+		}
+
+		if err := r.backend.Delete(ctx, &replicas[i]); err != nil {
+			return deleted, errors.Wrapf(err, "failed to delete replica '%s/%s'", replicas[i].Namespace, replicas[i].Name)
+		}
+	}
+
+	return deleted, nil
+}
+
+// Delete removes all versions of the secret in the source namespace and all
+// of their replicas
+func (r Replicator) Delete(ctx context.Context, sourceNamespace string, secretName string) error {
+	if err := r.store.Delete(ctx, sourceNamespace, secretName); err != nil {
+		return err
+	}
+
+	return r.DeleteReplicas(ctx, sourceNamespace, secretName)
+}
+
+// listReplicas returns the replicas of the secret in all namespaces
+func (r Replicator) listReplicas(ctx context.Context, sourceNamespace string, secretName string) ([]corev1.Secret, error) {
+	matchLabels := map[string]string{
+		LabelSecretKind:             VersionSecretKind,
+		LabelReplicaSourceNamespace: sourceNamespace,
+	}
+
+	secrets, err := r.backend.List(ctx, metav1.NamespaceAll, matchLabels)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list replicas of secret '%s/%s'", sourceNamespace, secretName)
+	}
+
+	result := []corev1.Secret{}
+	nameRegex := versionedNameRegex(secretName)
+	for _, secret := range secrets.Items {
+		if nameRegex.MatchString(secret.Name) {
+			result = append(result, secret)
+		}
+	}
+
+	return result, nil
+}
+
+// replica returns a copy of the source version for the target namespace
+func replica(source corev1.Secret, namespace string) *corev1.Secret {
+	labels := copyMap(source.Labels)
+	labels[LabelReplicaSourceNamespace] = source.Namespace
+
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        source.Name,
+			Namespace:   namespace,
+			Labels:      labels,
+			Annotations: copyMap(source.Annotations),
+		},
+		Type:      source.Type,
+		Data:      source.Data,
+		Immutable: pointers.Bool(true),
+	}
+}
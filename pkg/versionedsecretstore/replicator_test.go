@@ -0,0 +1,202 @@
+package versionedsecretstore_test
+
+import (
+	"context"
+
+	"github.com/spf13/afero"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "code.cloudfoundry.org/quarks-utils/pkg/versionedsecretstore"
+	"code.cloudfoundry.org/quarks-utils/testing"
+)
+
+var _ = Describe("Replicator", func() {
+	var (
+		clientset       *fake.Clientset
+		store           VersionedSecretStore
+		replicator      Replicator
+		ctx             context.Context
+		namespaceLabels map[string]string
+	)
+
+	namespace := func(name string, labels map[string]string) *corev1.Namespace {
+		return &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels}}
+	}
+
+	create := func(data string) {
+		err := store.Create(
+			ctx,
+			"source",
+			"some-owner",
+			types.UID("d3d423b7-a57f-43b0-8305-79d484154e4f"),
+			"some-kind",
+			"fake-secret",
+			map[string]string{"password": data},
+			nil,
+			map[string]string{},
+			"created by a unit-test",
+		)
+		Expect(err).ToNot(HaveOccurred())
+	}
+
+	secretNames := func(namespace string) []string {
+		list, err := clientset.CoreV1().Secrets(namespace).List(ctx, metav1.ListOptions{})
+		Expect(err).ToNot(HaveOccurred())
+		result := []string{}
+		for _, s := range list.Items {
+			result = append(result, s.Name)
+		}
+		return result
+	}
+
+	BeforeEach(func() {
+		namespaceLabels = map[string]string{"replicate": "true"}
+		clientset = fake.NewSimpleClientset(
+			namespace("source", namespaceLabels),
+			namespace("target-1", namespaceLabels),
+			namespace("target-2", namespaceLabels),
+			namespace("other", nil),
+		)
+		store = NewClientsetVersionedSecretStore(clientset)
+		replicator = NewClientsetReplicator(clientset)
+		ctx = testing.NewContext()
+
+		create("foo")
+		create("bar")
+	})
+
+	It("mirrors all versions to the selected namespaces", func() {
+		Expect(replicator.Replicate(ctx, "source", "fake-secret", namespaceLabels)).To(Succeed())
+
+		Expect(secretNames("target-1")).To(ConsistOf("fake-secret-v1", "fake-secret-v2"))
+		Expect(secretNames("target-2")).To(ConsistOf("fake-secret-v1", "fake-secret-v2"))
+		Expect(secretNames("other")).To(BeEmpty())
+
+		replica, err := NewClientsetVersionedSecretStore(clientset).Latest(ctx, "target-1", "fake-secret")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(replica.Name).To(Equal("fake-secret-v2"))
+		Expect(replica.Labels).To(HaveKeyWithValue(LabelReplicaSourceNamespace, "source"))
+		Expect(replica.OwnerReferences).To(BeEmpty())
+		Expect(*replica.Immutable).To(BeTrue())
+	})
+
+	It("keeps version numbers aligned when the source changes", func() {
+		Expect(replicator.Replicate(ctx, "source", "fake-secret", namespaceLabels)).To(Succeed())
+
+		create("baz")
+		Expect(clientset.CoreV1().Secrets("source").Delete(ctx, "fake-secret-v1", metav1.DeleteOptions{})).To(Succeed())
+		Expect(replicator.Replicate(ctx, "source", "fake-secret", namespaceLabels)).To(Succeed())
+
+		Expect(secretNames("target-1")).To(ConsistOf("fake-secret-v2", "fake-secret-v3"))
+	})
+
+	It("copies encrypted versions without decrypting them", func() {
+		fs := afero.NewMemMapFs()
+		Expect(afero.WriteFile(fs, "/keys.yml", []byte("primary: k\nkeys:\n  k: YWFhYWFhYWFhYWFhYWFhYWFhYWFhYWFhYWFhYWFhYWE=\n"), 0600)).To(Succeed())
+		store = NewClientsetVersionedSecretStore(clientset).WithEncryption(NewFileKeySource(fs, "/keys.yml"))
+		create("encrypted")
+
+		Expect(replicator.Replicate(ctx, "source", "fake-secret", namespaceLabels)).To(Succeed())
+
+		replica, err := clientset.CoreV1().Secrets("target-1").Get(ctx, "fake-secret-v3", metav1.GetOptions{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(replica.Annotations).To(HaveKey(AnnotationEncryptionKey))
+		Expect(string(replica.Data["password"])).ToNot(Equal("encrypted"))
+	})
+
+	It("checks that the key source can decrypt encrypted versions", func() {
+		fs := afero.NewMemMapFs()
+		Expect(afero.WriteFile(fs, "/keys.yml", []byte("primary: k\nkeys:\n  k: YWFhYWFhYWFhYWFhYWFhYWFhYWFhYWFhYWFhYWFhYWE=\n"), 0600)).To(Succeed())
+		Expect(afero.WriteFile(fs, "/other.yml", []byte("primary: o\nkeys:\n  o: YmJiYmJiYmJiYmJiYmJiYmJiYmJiYmJiYmJiYmJiYmI=\n"), 0600)).To(Succeed())
+		store = NewClientsetVersionedSecretStore(clientset).WithEncryption(NewFileKeySource(fs, "/keys.yml"))
+		create("encrypted")
+
+		err := replicator.WithKeySource(NewFileKeySource(fs, "/other.yml")).Replicate(ctx, "source", "fake-secret", namespaceLabels)
+		Expect(err).To(MatchError(ContainSubstring("key source of the target namespaces can't decrypt it")))
+		Expect(secretNames("target-1")).To(BeEmpty())
+
+		Expect(replicator.WithKeySource(NewFileKeySource(fs, "/keys.yml")).Replicate(ctx, "source", "fake-secret", namespaceLabels)).To(Succeed())
+		Expect(secretNames("target-1")).To(ConsistOf("fake-secret-v1", "fake-secret-v2", "fake-secret-v3"))
+	})
+
+	It("keeps local versions with the same name in target namespaces", func() {
+		local := NewClientsetVersionedSecretStore(clientset)
+		err := local.CreateWithOptions(ctx, CreateOptions{
+			Namespace:  "target-1",
+			Name:       "fake-secret",
+			StringData: map[string]string{"password": "local"},
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(replicator.Replicate(ctx, "source", "fake-secret", namespaceLabels)).To(Succeed())
+
+		Expect(secretNames("target-1")).To(ConsistOf("fake-secret-v1", "fake-secret-v2"))
+		Expect(secretNames("target-2")).To(ConsistOf("fake-secret-v1", "fake-secret-v2"))
+
+		kept, err := local.Get(ctx, "target-1", "fake-secret", 1)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(kept.Data["password"])).To(Equal("local"))
+		Expect(kept.Labels).ToNot(HaveKey(LabelReplicaSourceNamespace))
+
+		// Replicating again does not touch the local version
+		Expect(replicator.Replicate(ctx, "source", "fake-secret", namespaceLabels)).To(Succeed())
+		Expect(secretNames("target-1")).To(ConsistOf("fake-secret-v1", "fake-secret-v2"))
+	})
+
+	It("removes replicas from namespaces which are no longer selected", func() {
+		Expect(replicator.Replicate(ctx, "source", "fake-secret", namespaceLabels)).To(Succeed())
+
+		_, err := clientset.CoreV1().Namespaces().Update(ctx, namespace("target-2", nil), metav1.UpdateOptions{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(replicator.Replicate(ctx, "source", "fake-secret", namespaceLabels)).To(Succeed())
+
+		Expect(secretNames("target-1")).To(HaveLen(2))
+		Expect(secretNames("target-2")).To(BeEmpty())
+	})
+
+	It("cleans up the replicas when the source is deleted", func() {
+		Expect(replicator.Replicate(ctx, "source", "fake-secret", namespaceLabels)).To(Succeed())
+
+		Expect(store.Delete(ctx, "source", "fake-secret")).To(Succeed())
+		Expect(replicator.Replicate(ctx, "source", "fake-secret", namespaceLabels)).To(Succeed())
+
+		Expect(secretNames("target-1")).To(BeEmpty())
+		Expect(secretNames("target-2")).To(BeEmpty())
+	})
+
+	It("removes the replicas of pruned versions", func() {
+		create("baz")
+		Expect(replicator.Replicate(ctx, "source", "fake-secret", namespaceLabels)).To(Succeed())
+
+		deleted, err := replicator.Prune(ctx, "source", "fake-secret", 1)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(deleted).To(ConsistOf("fake-secret-v1", "fake-secret-v2"))
+
+		Expect(secretNames("source")).To(ConsistOf("fake-secret-v3"))
+		Expect(secretNames("target-1")).To(ConsistOf("fake-secret-v3"))
+		Expect(secretNames("target-2")).To(ConsistOf("fake-secret-v3"))
+	})
+
+	It("removes the replicas when deleting the source", func() {
+		Expect(replicator.Replicate(ctx, "source", "fake-secret", namespaceLabels)).To(Succeed())
+		Expect(replicator.Delete(ctx, "source", "fake-secret")).To(Succeed())
+
+		Expect(secretNames("source")).To(BeEmpty())
+		Expect(secretNames("target-1")).To(BeEmpty())
+		Expect(secretNames("target-2")).To(BeEmpty())
+	})
+
+	It("deletes all replicas", func() {
+		Expect(replicator.Replicate(ctx, "source", "fake-secret", namespaceLabels)).To(Succeed())
+		Expect(replicator.DeleteReplicas(ctx, "source", "fake-secret")).To(Succeed())
+
+		Expect(secretNames("target-1")).To(BeEmpty())
+		Expect(secretNames("source")).To(HaveLen(2))
+	})
+})
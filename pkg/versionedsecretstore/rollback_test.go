@@ -0,0 +1,90 @@
+package versionedsecretstore_test
+
+import (
+	"context"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "code.cloudfoundry.org/quarks-utils/pkg/versionedsecretstore"
+	"code.cloudfoundry.org/quarks-utils/testing"
+)
+
+var _ = Describe("Rollback and Prune", func() {
+	var (
+		clientset *fake.Clientset
+		store     VersionedSecretStore
+		ctx       context.Context
+	)
+
+	create := func(password string) {
+		err := store.CreateWithOptions(ctx, CreateOptions{
+			Namespace:         "default",
+			Name:              "fake-secret",
+			StringData:        map[string]string{"password": password},
+			Labels:            map[string]string{"app": "fake"},
+			SourceDescription: "created by a unit-test",
+		})
+		Expect(err).ToNot(HaveOccurred())
+	}
+
+	BeforeEach(func() {
+		clientset = fake.NewSimpleClientset()
+		store = NewClientsetVersionedSecretStore(clientset)
+		ctx = testing.NewContext()
+
+		create("foo")
+		create("bar")
+		create("baz")
+	})
+
+	Describe("Rollback", func() {
+		It("creates a new version with the content of the old version", func() {
+			Expect(store.Rollback(ctx, "default", "fake-secret", 1)).To(Succeed())
+
+			latest, err := store.Latest(ctx, "default", "fake-secret")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(latest.Name).To(Equal("fake-secret-v4"))
+			Expect(string(latest.Data["password"])).To(Equal("foo"))
+			Expect(latest.Labels).To(HaveKeyWithValue("app", "fake"))
+			Expect(latest.Labels).To(HaveKeyWithValue(LabelVersion, "4"))
+			Expect(latest.Annotations).To(HaveKeyWithValue(AnnotationSourceDescription, "rollback to version 1"))
+		})
+
+		It("fails if the latest version already has the content", func() {
+			err := store.Rollback(ctx, "default", "fake-secret", 3)
+			Expect(IsSecretIdenticalError(err)).To(BeTrue())
+		})
+
+		It("fails if the version does not exist", func() {
+			Expect(store.Rollback(ctx, "default", "fake-secret", 7)).ToNot(Succeed())
+		})
+	})
+
+	Describe("Prune", func() {
+		It("deletes all but the newest versions", func() {
+			create("qux")
+
+			deleted, err := store.Prune(ctx, "default", "fake-secret", 2)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(deleted).To(ConsistOf("fake-secret-v1", "fake-secret-v2"))
+
+			list, err := clientset.CoreV1().Secrets("default").List(ctx, metav1.ListOptions{})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(list.Items).To(HaveLen(2))
+		})
+
+		It("always keeps the latest version", func() {
+			deleted, err := store.Prune(ctx, "default", "fake-secret", 0)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(deleted).To(HaveLen(2))
+
+			latest, err := store.Latest(ctx, "default", "fake-secret")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(latest.Name).To(Equal("fake-secret-v3"))
+		})
+	})
+})
@@ -0,0 +1,193 @@
+package versionedsecretstore
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"reflect"
+
+	"go.uber.org/zap"
+	admissionregistration "k8s.io/api/admissionregistration/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"code.cloudfoundry.org/quarks-utils/pkg/config"
+	"code.cloudfoundry.org/quarks-utils/pkg/monitorednamespace"
+	"code.cloudfoundry.org/quarks-utils/pkg/names"
+	wh "code.cloudfoundry.org/quarks-utils/pkg/webhook"
+)
+
+// Validator rejects updates which alter the data of a versioned secret
+type Validator struct {
+	log      *zap.SugaredLogger
+	decoder  *admission.Decoder
+	envelope *envelope
+}
+
+var _ admission.Handler = &Validator{}
+var _ admission.DecoderInjector = &Validator{}
+
+// NewValidator returns a new Validator
+func NewValidator(log *zap.SugaredLogger) *Validator {
+	return &Validator{log: log}
+}
+
+// WithKeySource returns a copy of the validator, which accepts data
+// encryption keys re-wrapped with a key of the key source, like RotateKeys
+// does. Without a key source, all changes to the AnnotationEncryptionKey
+// annotation are rejected.
+func (v *Validator) WithKeySource(source KeySource) *Validator {
+	validator := *v
+	validator.envelope = &envelope{source: source}
+	return &validator
+}
+
+// NewSecretValidator returns a webhook, which rejects edits to the data of
+// versioned secrets in monitored namespaces
+func NewSecretValidator(log *zap.SugaredLogger, config *config.Config) *wh.OperatorWebhook {
+	log.Info("Setting up validator for versioned secrets")
+	return newSecretValidatorWebhook(NewValidator(log), config)
+}
+
+// NewEncryptedSecretValidator returns a webhook like NewSecretValidator,
+// which also accepts key rotations of encrypted versioned secrets with the
+// keys of the key source
+func NewEncryptedSecretValidator(log *zap.SugaredLogger, config *config.Config, source KeySource) *wh.OperatorWebhook {
+	log.Info("Setting up validator for encrypted versioned secrets")
+	return newSecretValidatorWebhook(NewValidator(log).WithKeySource(source), config)
+}
+
+func newSecretValidatorWebhook(validator *Validator, config *config.Config) *wh.OperatorWebhook {
+	scope := admissionregistration.NamespacedScope
+	return &wh.OperatorWebhook{
+		FailurePolicy: admissionregistration.Fail,
+		Rules: []admissionregistration.RuleWithOperations{
+			{
+				Rule: admissionregistration.Rule{
+					APIGroups:   []string{""},
+					APIVersions: []string{"v1"},
+					Resources:   []string{"secrets"},
+					Scope:       &scope,
+				},
+				Operations: []admissionregistration.OperationType{
+					admissionregistration.Update,
+				},
+			},
+		},
+		Path: "/validate-versioned-secret",
+		Name: "validate-versioned-secret." + names.GroupName,
+		NamespaceSelector: &metav1.LabelSelector{
+			MatchLabels: map[string]string{
+				monitorednamespace.LabelNamespace: config.MonitoredID,
+			},
+		},
+		Handler: validator,
+		Webhook: &admission.Webhook{
+			Handler: validator,
+		},
+	}
+}
+
+// Handle denies updates to versioned secrets, if they change the data, the
+// type, the labels which identify the version or the content hash. Changes
+// to the wrapped encryption key are only allowed for key rotations.
+func (v *Validator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	oldSecret := &corev1.Secret{}
+	if err := v.decoder.DecodeRaw(req.OldObject, oldSecret); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	if !IsVersionedSecret(*oldSecret) {
+		return admission.Allowed("not a versioned secret")
+	}
+
+	secret := &corev1.Secret{}
+	if err := v.decoder.DecodeRaw(req.Object, secret); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	msg := validateUpdate(oldSecret, secret)
+	if msg == "" {
+		msg = v.validateEncryptionKey(ctx, oldSecret, secret)
+	}
+	if msg != "" {
+		v.log.Infof("Rejecting update to versioned secret '%s/%s': %s", oldSecret.Namespace, oldSecret.Name, msg)
+		return admission.Denied(msg)
+	}
+
+	return admission.Allowed("versioned secret data is unchanged")
+}
+
+// InjectDecoder injects the decoder.
+func (v *Validator) InjectDecoder(d *admission.Decoder) error {
+	v.decoder = d
+	return nil
+}
+
+// validateUpdate returns a message describing the forbidden change, or an empty string
+func validateUpdate(oldSecret *corev1.Secret, secret *corev1.Secret) string {
+	if !reflect.DeepEqual(normalizeData(oldSecret.Data), normalizeData(secret.Data)) || len(secret.StringData) > 0 {
+		return fmt.Sprintf("the data of versioned secret '%s' cannot be altered, create a new version instead", oldSecret.Name)
+	}
+
+	if oldSecret.Type != secret.Type {
+		return fmt.Sprintf("the type of versioned secret '%s' cannot be altered", oldSecret.Name)
+	}
+
+	for _, label := range []string{LabelSecretKind, LabelVersion} {
+		if oldSecret.Labels[label] != secret.Labels[label] {
+			return fmt.Sprintf("the label '%s' of versioned secret '%s' cannot be altered", label, oldSecret.Name)
+		}
+	}
+
+	if oldSecret.Annotations[AnnotationContentHash] != secret.Annotations[AnnotationContentHash] {
+		return fmt.Sprintf("the annotation '%s' of versioned secret '%s' cannot be altered", AnnotationContentHash, oldSecret.Name)
+	}
+
+	return ""
+}
+
+// validateEncryptionKey returns a message if the AnnotationEncryptionKey
+// annotation changed, unless the change is a key rotation: the new value has
+// to wrap the same data encryption key with a key of the key set.
+func (v *Validator) validateEncryptionKey(ctx context.Context, oldSecret *corev1.Secret, secret *corev1.Secret) string {
+	oldWrapped, oldOK := oldSecret.Annotations[AnnotationEncryptionKey]
+	wrapped, ok := secret.Annotations[AnnotationEncryptionKey]
+	if oldOK == ok && oldWrapped == wrapped {
+		return ""
+	}
+
+	forbidden := fmt.Sprintf("the annotation '%s' of versioned secret '%s' cannot be altered", AnnotationEncryptionKey, oldSecret.Name)
+	if !oldOK || !ok || v.envelope == nil {
+		return forbidden
+	}
+
+	keySet, err := v.envelope.source.KeySet(ctx)
+	if err != nil {
+		v.log.Errorf("Failed to read key set to validate key rotation of '%s/%s': %v", oldSecret.Namespace, oldSecret.Name, err)
+		return forbidden + ", the key set is unavailable"
+	}
+
+	oldDEK, _, err := unwrapKey(keySet, oldWrapped)
+	if err != nil {
+		return forbidden + ": " + err.Error()
+	}
+	dek, _, err := unwrapKey(keySet, wrapped)
+	if err != nil {
+		return forbidden + ": " + err.Error()
+	}
+	if subtle.ConstantTimeCompare(oldDEK, dek) != 1 {
+		return forbidden + ", it has to wrap the same data encryption key"
+	}
+	return ""
+}
+
+// normalizeData treats nil and empty data maps as equal
+func normalizeData(data map[string][]byte) map[string][]byte {
+	if len(data) == 0 {
+		return map[string][]byte{}
+	}
+	return data
+}
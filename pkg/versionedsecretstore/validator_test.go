@@ -0,0 +1,194 @@
+package versionedsecretstore_test
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/afero"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"code.cloudfoundry.org/quarks-utils/pkg/config"
+	. "code.cloudfoundry.org/quarks-utils/pkg/versionedsecretstore"
+	"code.cloudfoundry.org/quarks-utils/testing"
+	helper "code.cloudfoundry.org/quarks-utils/testing/testhelper"
+)
+
+var _ = Describe("Validator", func() {
+	var (
+		validator *Validator
+		oldSecret *corev1.Secret
+		newSecret *corev1.Secret
+		ctx       context.Context
+	)
+
+	request := func(oldSecret, newSecret *corev1.Secret) admission.Request {
+		oldRaw, err := json.Marshal(oldSecret)
+		Expect(err).ToNot(HaveOccurred())
+		newRaw, err := json.Marshal(newSecret)
+		Expect(err).ToNot(HaveOccurred())
+
+		return admission.Request{
+			AdmissionRequest: admissionv1.AdmissionRequest{
+				Operation: admissionv1.Update,
+				OldObject: runtime.RawExtension{Raw: oldRaw},
+				Object:    runtime.RawExtension{Raw: newRaw},
+			},
+		}
+	}
+
+	BeforeEach(func() {
+		_, log := helper.NewTestLogger()
+		validator = NewValidator(log)
+		decoder, err := admission.NewDecoder(scheme.Scheme)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(validator.InjectDecoder(decoder)).To(Succeed())
+		ctx = testing.NewContext()
+
+		oldSecret = &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "fake-deployment-v1",
+				Namespace: "default",
+				Labels: map[string]string{
+					LabelSecretKind: VersionSecretKind,
+					LabelVersion:    "1",
+				},
+			},
+			Data: map[string][]byte{"manifest": []byte("foo")},
+		}
+		newSecret = oldSecret.DeepCopy()
+	})
+
+	It("creates an operator webhook for secret updates", func() {
+		_, log := helper.NewTestLogger()
+		wh := NewSecretValidator(log, &config.Config{MonitoredID: "id"})
+		Expect(wh.Path).To(Equal("/validate-versioned-secret"))
+		Expect(wh.Rules[0].Resources).To(ConsistOf("secrets"))
+		Expect(wh.Webhook.Handler).ToNot(BeNil())
+	})
+
+	It("allows changes to the labels of a versioned secret", func() {
+		newSecret.Labels["foo"] = "bar"
+		response := validator.Handle(ctx, request(oldSecret, newSecret))
+		Expect(response.Allowed).To(BeTrue())
+	})
+
+	It("allows changes to the data of other secrets", func() {
+		delete(oldSecret.Labels, LabelSecretKind)
+		newSecret.Data["manifest"] = []byte("bar")
+		response := validator.Handle(ctx, request(oldSecret, newSecret))
+		Expect(response.Allowed).To(BeTrue())
+	})
+
+	It("denies changes to the data of a versioned secret", func() {
+		newSecret.Data["manifest"] = []byte("bar")
+		response := validator.Handle(ctx, request(oldSecret, newSecret))
+		Expect(response.Allowed).To(BeFalse())
+		Expect(string(response.Result.Reason)).To(ContainSubstring("cannot be altered"))
+	})
+
+	It("denies changes to the version label of a versioned secret", func() {
+		newSecret.Labels[LabelVersion] = "2"
+		response := validator.Handle(ctx, request(oldSecret, newSecret))
+		Expect(response.Allowed).To(BeFalse())
+	})
+
+	Context("when the secret is encrypted", func() {
+		var (
+			fs    afero.Fs
+			store VersionedSecretImpl
+		)
+
+		key := func(b byte) string {
+			return base64.StdEncoding.EncodeToString([]byte(strings.Repeat(string(b), 32)))
+		}
+
+		writeKeySet := func(primary string, keys map[string]string) {
+			content := fmt.Sprintf("primary: %s\nkeys:\n", primary)
+			for id, k := range keys {
+				content += fmt.Sprintf("  %s: %s\n", id, k)
+			}
+			Expect(afero.WriteFile(fs, "/keys.yml", []byte(content), 0600)).To(Succeed())
+		}
+
+		raw := func(clientset *fake.Clientset) *corev1.Secret {
+			secret, err := clientset.CoreV1().Secrets("default").Get(ctx, "fake-secret-v1", metav1.GetOptions{})
+			Expect(err).ToNot(HaveOccurred())
+			return secret
+		}
+
+		BeforeEach(func() {
+			fs = afero.NewMemMapFs()
+			writeKeySet("key-1", map[string]string{"key-1": key('a')})
+			source := NewFileKeySource(fs, "/keys.yml")
+			validator = validator.WithKeySource(source)
+
+			clientset := fake.NewSimpleClientset()
+			store = NewClientsetVersionedSecretStore(clientset).WithEncryption(source)
+			Expect(store.Create(ctx, "default", "some-owner", types.UID("d3d423b7-a57f-43b0-8305-79d484154e4f"), "some-kind",
+				"fake-secret", map[string]string{"password": "secret-password"}, nil, map[string]string{}, "created by a unit-test",
+			)).To(Succeed())
+			oldSecret = raw(clientset)
+
+			writeKeySet("key-2", map[string]string{"key-1": key('a'), "key-2": key('b')})
+			Expect(store.RotateKeys(ctx, "default", "fake-secret")).To(Succeed())
+			newSecret = raw(clientset)
+		})
+
+		It("allows key rotations", func() {
+			Expect(newSecret.Annotations[AnnotationEncryptionKey]).ToNot(Equal(oldSecret.Annotations[AnnotationEncryptionKey]))
+			response := validator.Handle(ctx, request(oldSecret, newSecret))
+			Expect(response.Allowed).To(BeTrue())
+		})
+
+		It("denies key rotations without a key source", func() {
+			_, log := helper.NewTestLogger()
+			validator = NewValidator(log)
+			decoder, err := admission.NewDecoder(scheme.Scheme)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(validator.InjectDecoder(decoder)).To(Succeed())
+
+			response := validator.Handle(ctx, request(oldSecret, newSecret))
+			Expect(response.Allowed).To(BeFalse())
+			Expect(string(response.Result.Reason)).To(ContainSubstring(AnnotationEncryptionKey))
+		})
+
+		It("denies keys which are not in the key set", func() {
+			writeKeySet("key-1", map[string]string{"key-1": key('a')})
+			response := validator.Handle(ctx, request(oldSecret, newSecret))
+			Expect(response.Allowed).To(BeFalse())
+		})
+
+		It("denies keys which wrap a different data encryption key", func() {
+			other := fake.NewSimpleClientset()
+			store = NewClientsetVersionedSecretStore(other).WithEncryption(NewFileKeySource(fs, "/keys.yml"))
+			Expect(store.Create(ctx, "default", "some-owner", types.UID("d3d423b7-a57f-43b0-8305-79d484154e4f"), "some-kind",
+				"fake-secret", map[string]string{"password": "secret-password"}, nil, map[string]string{}, "created by a unit-test",
+			)).To(Succeed())
+			newSecret.Annotations[AnnotationEncryptionKey] = raw(other).Annotations[AnnotationEncryptionKey]
+
+			response := validator.Handle(ctx, request(oldSecret, newSecret))
+			Expect(response.Allowed).To(BeFalse())
+			Expect(string(response.Result.Reason)).To(ContainSubstring("same data encryption key"))
+		})
+
+		It("denies removing the key", func() {
+			delete(newSecret.Annotations, AnnotationEncryptionKey)
+			response := validator.Handle(ctx, request(oldSecret, newSecret))
+			Expect(response.Allowed).To(BeFalse())
+		})
+	})
+})
@@ -0,0 +1,19 @@
+package versionedsecretstore
+
+import (
+	corev1 "k8s.io/api/core/v1"
+)
+
+// IsVersionedConfigMap returns true if the config map has a label identifying it as versioned config map
+func IsVersionedConfigMap(configMap corev1.ConfigMap) bool {
+	if kind, ok := configMap.GetLabels()[LabelSecretKind]; ok && kind == VersionConfigMapKind {
+		return true
+	}
+
+	return false
+}
+
+// ConfigMapVersion returns the versioned config maps version from the labels
+func ConfigMapVersion(configMap corev1.ConfigMap) (int, error) {
+	return versionFromLabels(configMap.Name, configMap.Labels)
+}
@@ -0,0 +1,258 @@
+package versionedsecretstore
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"code.cloudfoundry.org/quarks-utils/pkg/pointers"
+)
+
+const (
+	// VersionConfigMapKind is the kind of versioned config map
+	VersionConfigMapKind = "versionedConfigMap"
+)
+
+var _ VersionedConfigMapStore = &VersionedConfigMapImpl{}
+
+// ConfigMapIdenticalError indicates cases where the latest config map version is identical to the one to be created
+type ConfigMapIdenticalError struct {
+	configMap *corev1.ConfigMap
+}
+
+func (e ConfigMapIdenticalError) Error() string {
+	return fmt.Sprintf("The latest version of the versioned config map '%s/%s' is identical to the one to be created.", e.configMap.Namespace, e.configMap.Name)
+}
+
+// IsConfigMapIdenticalError returns whether the error object is a ConfigMapIdenticalError
+func IsConfigMapIdenticalError(e error) bool {
+	switch e.(type) {
+	case ConfigMapIdenticalError:
+		return true
+	}
+	return false
+}
+
+type versionedConfigMapStoreBackend interface {
+	Create(ctx context.Context, configMap *corev1.ConfigMap) error
+	Get(ctx context.Context, nn types.NamespacedName) (*corev1.ConfigMap, error)
+	Patch(ctx context.Context, configMap *corev1.ConfigMap, patch []byte) error
+	Delete(ctx context.Context, configMap *corev1.ConfigMap) error
+	List(ctx context.Context, namespace string, matchLabels map[string]string) (*corev1.ConfigMapList, error)
+}
+
+// VersionedConfigMapStore is the interface to version config maps in Kubernetes
+//
+// It follows the same rules as the VersionedSecretStore: each update results
+// in a new, immutable version named `<name>-v<version>`, and deleting the
+// config map removes all of its versions. Each version carries a hash of its
+// data and binary data in the AnnotationContentHash annotation.
+type VersionedConfigMapStore interface {
+	SetConfigMapReferences(ctx context.Context, namespace string, podSpec *corev1.PodSpec) error
+	Create(ctx context.Context, namespace string, ownerName string, ownerID types.UID, ownerKind string, configMapName string, data map[string]string, annotations map[string]string, labels map[string]string, sourceDescription string) error
+	CreateWithOwners(ctx context.Context, namespace string, owners []metav1.OwnerReference, configMapName string, data map[string]string, annotations map[string]string, labels map[string]string, sourceDescription string) error
+	CreateWithOptions(ctx context.Context, opts CreateOptions) error
+	Get(ctx context.Context, namespace string, configMapName string, version int) (*corev1.ConfigMap, error)
+	Latest(ctx context.Context, namespace string, configMapName string) (*corev1.ConfigMap, error)
+	List(ctx context.Context, namespace string, configMapName string) ([]corev1.ConfigMap, error)
+	VersionCount(ctx context.Context, namespace string, configMapName string) (int, error)
+	Delete(ctx context.Context, namespace string, configMapName string) error
+	Prune(ctx context.Context, namespace string, configMapName string, keep int) ([]string, error)
+	Decorate(ctx context.Context, namespace string, configMapName string, key string, value string) error
+	DecorateVersion(ctx context.Context, namespace string, configMapName string, version int, decoration Decoration, actor string) error
+	LatestDecorated(ctx context.Context, namespace string, configMapName string, decoration Decoration) (*corev1.ConfigMap, error)
+	DecorationHistory(ctx context.Context, namespace string, configMapName string) ([]DecorationRecord, error)
+}
+
+// VersionedConfigMapImpl contains the required fields to persist a config map
+type VersionedConfigMapImpl struct {
+	versions versionedObjectStore
+}
+
+// NewVersionedConfigMapStore returns a VersionedConfigMapStore implementation
+// using a controller-runtime client backend
+func NewVersionedConfigMapStore(client client.Client) VersionedConfigMapImpl {
+	return newVersionedConfigMapImpl(&versionedConfigMapStoreClientBackend{client: client})
+}
+
+// NewClientsetVersionedConfigMapStore returns a VersionedConfigMapStore using a kubernetes.Clientset backend
+func NewClientsetVersionedConfigMapStore(clientset kubernetes.Interface) VersionedConfigMapImpl {
+	return newVersionedConfigMapImpl(&versionedConfigMapStoreClientsetBackend{clientset: clientset})
+}
+
+func newVersionedConfigMapImpl(backend versionedConfigMapStoreBackend) VersionedConfigMapImpl {
+	return VersionedConfigMapImpl{
+		versions: versionedObjectStore{backend: configMapVersions{backend: backend}},
+	}
+}
+
+// SetConfigMapReferences update versioned config map references in pod spec
+func (p VersionedConfigMapImpl) SetConfigMapReferences(ctx context.Context, namespace string, podSpec *corev1.PodSpec) error {
+	return p.versions.setReferences(ctx, namespace, podSpec)
+}
+
+// Create creates a new version of the config map from data.
+// The owner is referenced as a controller in the quarks API group, use
+// CreateWithOwners for other owners.
+func (p VersionedConfigMapImpl) Create(ctx context.Context,
+	namespace string,
+	ownerName string,
+	ownerID types.UID,
+	ownerKind string,
+	configMapName string,
+	data map[string]string,
+	annotations map[string]string,
+	labels map[string]string,
+	sourceDescription string) error {
+
+	owners := []metav1.OwnerReference{controllerOwnerReference(ownerName, ownerID, ownerKind)}
+	return p.CreateWithOwners(ctx, namespace, owners, configMapName, data, annotations, labels, sourceDescription)
+}
+
+// CreateWithOwners creates a new version of the config map from data,
+// referencing all of the given owners. At most one of them may be a
+// controller, see NewOwnerReference.
+func (p VersionedConfigMapImpl) CreateWithOwners(ctx context.Context,
+	namespace string,
+	owners []metav1.OwnerReference,
+	configMapName string,
+	data map[string]string,
+	annotations map[string]string,
+	labels map[string]string,
+	sourceDescription string) error {
+
+	return p.CreateWithOptions(ctx, CreateOptions{
+		Namespace:         namespace,
+		Name:              configMapName,
+		StringData:        data,
+		Labels:            labels,
+		Annotations:       annotations,
+		Owners:            owners,
+		SourceDescription: sourceDescription,
+	})
+}
+
+// CreateWithOptions creates a new version of the config map. StringData is
+// stored as the data of the config map and Data as its binary data, Type is
+// ignored.
+func (p VersionedConfigMapImpl) CreateWithOptions(ctx context.Context, opts CreateOptions) error {
+	namespace, configMapName := opts.Namespace, opts.Name
+
+	if err := validateOwners(opts.Owners); err != nil {
+		return errors.Wrapf(err, "invalid owners for versioned config map '%s/%s'", namespace, configMapName)
+	}
+
+	data := copyMap(opts.StringData)
+	binaryData := copyBinaryMap(opts.Data)
+	for k := range data {
+		if _, ok := binaryData[k]; ok {
+			return errors.Errorf("key '%s' of versioned config map '%s/%s' is used for data and binary data", k, namespace, configMapName)
+		}
+	}
+
+	labels, annotations := opts.metadata()
+	contentHash := ConfigMapDataHash(data, binaryData)
+	annotations[AnnotationContentHash] = contentHash
+
+	return p.versions.create(ctx, newVersion{
+		namespace:   namespace,
+		name:        configMapName,
+		labels:      labels,
+		annotations: annotations,
+		owners:      opts.Owners,
+		identicalContent: func(latest metav1.Object) bool {
+			return ConfigMapContentHash(*latest.(*corev1.ConfigMap)) == contentHash
+		},
+		build: func(meta metav1.ObjectMeta) (metav1.Object, error) {
+			return &corev1.ConfigMap{
+				ObjectMeta: meta,
+				Data:       data,
+				BinaryData: binaryData,
+				// Clusters without support for immutable config maps drop this field
+				Immutable: pointers.Bool(true),
+			}, nil
+		},
+	})
+}
+
+// Get returns a specific version of the config map
+func (p VersionedConfigMapImpl) Get(ctx context.Context, namespace string, configMapName string, version int) (*corev1.ConfigMap, error) {
+	object, err := p.versions.get(ctx, namespace, configMapName, version)
+	if err != nil {
+		return nil, err
+	}
+	return object.(*corev1.ConfigMap), nil
+}
+
+// Latest returns the latest version of the config map
+func (p VersionedConfigMapImpl) Latest(ctx context.Context, namespace string, configMapName string) (*corev1.ConfigMap, error) {
+	object, err := p.versions.latest(ctx, namespace, configMapName)
+	if err != nil {
+		return nil, err
+	}
+	return object.(*corev1.ConfigMap), nil
+}
+
+// List returns all versions of the config map
+func (p VersionedConfigMapImpl) List(ctx context.Context, namespace string, configMapName string) ([]corev1.ConfigMap, error) {
+	list, err := p.versions.list(ctx, namespace, configMapName)
+	if err != nil {
+		return nil, err
+	}
+
+	configMaps := make([]corev1.ConfigMap, len(list))
+	for i, object := range list {
+		configMaps[i] = *object.(*corev1.ConfigMap)
+	}
+	return configMaps, nil
+}
+
+// VersionCount returns the number of versions for this config map
+func (p VersionedConfigMapImpl) VersionCount(ctx context.Context, namespace string, configMapName string) (int, error) {
+	return p.versions.count(ctx, namespace, configMapName)
+}
+
+// Decorate adds a label to the latest version of the config map.
+// It patches the labels only, so the data of the version stays untouched.
+func (p VersionedConfigMapImpl) Decorate(ctx context.Context, namespace string, configMapName string, key string, value string) error {
+	return p.versions.decorate(ctx, namespace, configMapName, key, value)
+}
+
+// DecorateVersion records the decoration on a specific version of the config
+// map, see VersionedSecretImpl.DecorateVersion
+func (p VersionedConfigMapImpl) DecorateVersion(ctx context.Context, namespace string, configMapName string, version int, decoration Decoration, actor string) error {
+	return p.versions.decorateVersion(ctx, namespace, configMapName, version, decoration, actor)
+}
+
+// LatestDecorated returns the latest version of the config map, which
+// carries the decoration. It returns a NotFound error if no version is decorated.
+func (p VersionedConfigMapImpl) LatestDecorated(ctx context.Context, namespace string, configMapName string, decoration Decoration) (*corev1.ConfigMap, error) {
+	object, err := p.versions.latestDecorated(ctx, namespace, configMapName, decoration)
+	if err != nil {
+		return nil, err
+	}
+	return object.(*corev1.ConfigMap), nil
+}
+
+// DecorationHistory returns the decorations of all versions of the config map, ordered by time
+func (p VersionedConfigMapImpl) DecorationHistory(ctx context.Context, namespace string, configMapName string) ([]DecorationRecord, error) {
+	return p.versions.decorationHistory(ctx, namespace, configMapName)
+}
+
+// Delete removes all versions of the config map and therefore the
+// config map itself.
+func (p VersionedConfigMapImpl) Delete(ctx context.Context, namespace string, configMapName string) error {
+	return p.versions.delete(ctx, namespace, configMapName)
+}
+
+// Prune removes all but the newest keep versions of the config map and
+// returns the names of the deleted versions. The latest version is never removed.
+func (p VersionedConfigMapImpl) Prune(ctx context.Context, namespace string, configMapName string, keep int) ([]string, error) {
+	return p.versions.prune(ctx, namespace, configMapName, keep)
+}
@@ -0,0 +1,248 @@
+package versionedsecretstore_test
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/kubernetes/scheme"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"code.cloudfoundry.org/quarks-utils/pkg/pointers"
+	. "code.cloudfoundry.org/quarks-utils/pkg/versionedsecretstore"
+	"code.cloudfoundry.org/quarks-utils/testing"
+)
+
+var _ = Describe("VersionedConfigMapStore", func() {
+	var (
+		namespace string
+		clientset *fake.Clientset
+		store     VersionedConfigMapStore
+		ctx       context.Context
+		data      map[string]string
+	)
+
+	create := func(data map[string]string) error {
+		return store.Create(
+			ctx,
+			namespace,
+			"some-owner",
+			types.UID("d3d423b7-a57f-43b0-8305-79d484154e4f"),
+			"some-kind",
+			"fake-config",
+			data,
+			nil,
+			map[string]string{"deployment-name": "fake-deployment"},
+			"created by a unit-test",
+		)
+	}
+
+	BeforeEach(func() {
+		namespace = "default"
+		clientset = fake.NewSimpleClientset()
+		store = NewClientsetVersionedConfigMapStore(clientset)
+		ctx = testing.NewContext()
+		data = map[string]string{"config.yml": "foo: bar"}
+	})
+
+	Describe("Create", func() {
+		It("creates immutable, labeled versions", func() {
+			Expect(create(data)).To(Succeed())
+
+			configMap, err := clientset.CoreV1().ConfigMaps(namespace).Get(ctx, "fake-config-v1", metav1.GetOptions{})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(*configMap.Immutable).To(BeTrue())
+			Expect(configMap.Data).To(Equal(data))
+			Expect(IsVersionedConfigMap(*configMap)).To(BeTrue())
+			Expect(configMap.Labels).To(HaveKeyWithValue(LabelVersion, "1"))
+			Expect(configMap.Annotations).To(HaveKeyWithValue(AnnotationSourceDescription, "created by a unit-test"))
+		})
+
+		It("does not create a new version if the content is identical", func() {
+			Expect(create(data)).To(Succeed())
+
+			err := create(map[string]string{"config.yml": "foo: bar"})
+			Expect(IsConfigMapIdenticalError(err)).To(BeTrue())
+
+			n, err := store.VersionCount(ctx, namespace, "fake-config")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(n).To(Equal(1))
+		})
+
+		It("creates a new version if the content changed", func() {
+			Expect(create(data)).To(Succeed())
+			Expect(create(map[string]string{"config.yml": "foo: baz"})).To(Succeed())
+
+			latest, err := store.Latest(ctx, namespace, "fake-config")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(latest.Name).To(Equal("fake-config-v2"))
+		})
+
+		It("does not modify the labels and annotations of the caller", func() {
+			labels := map[string]string{"deployment-name": "fake-deployment"}
+			annotations := map[string]string{"foo": "bar"}
+			Expect(store.Create(ctx, namespace, "some-owner", types.UID("d3d423b7-a57f-43b0-8305-79d484154e4f"), "some-kind",
+				"fake-config", data, annotations, labels, "created by a unit-test")).To(Succeed())
+
+			Expect(labels).To(Equal(map[string]string{"deployment-name": "fake-deployment"}))
+			Expect(annotations).To(Equal(map[string]string{"foo": "bar"}))
+		})
+	})
+
+	Describe("CreateWithOwners", func() {
+		It("references the given owners", func() {
+			owner := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "some-pod", UID: "ac4b3a2c-1f0e-4a4e-8b6c-4d5c8a1f9e0d"}}
+			ref, err := NewOwnerReference(owner, scheme.Scheme, true)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(store.CreateWithOwners(ctx, namespace, []metav1.OwnerReference{ref}, "fake-config", data, nil, nil, "created by a unit-test")).To(Succeed())
+
+			configMap, err := clientset.CoreV1().ConfigMaps(namespace).Get(ctx, "fake-config-v1", metav1.GetOptions{})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(configMap.OwnerReferences).To(HaveLen(1))
+			Expect(configMap.OwnerReferences[0].APIVersion).To(Equal("v1"))
+			Expect(configMap.OwnerReferences[0].Kind).To(Equal("Pod"))
+		})
+
+		It("rejects multiple controllers", func() {
+			owners := []metav1.OwnerReference{
+				{Name: "a", Controller: pointers.Bool(true)},
+				{Name: "b", Controller: pointers.Bool(true)},
+			}
+			err := store.CreateWithOwners(ctx, namespace, owners, "fake-config", data, nil, nil, "created by a unit-test")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("only one owner can be a controller"))
+		})
+	})
+
+	Describe("CreateWithOptions", func() {
+		opts := func(binaryData map[string][]byte) CreateOptions {
+			return CreateOptions{
+				Namespace:         namespace,
+				Name:              "fake-config",
+				StringData:        data,
+				Data:              binaryData,
+				SourceDescription: "created by a unit-test",
+			}
+		}
+
+		It("stores binary data", func() {
+			Expect(store.CreateWithOptions(ctx, opts(map[string][]byte{"blob": {0, 1, 2}}))).To(Succeed())
+
+			configMap, err := clientset.CoreV1().ConfigMaps(namespace).Get(ctx, "fake-config-v1", metav1.GetOptions{})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(configMap.Data).To(Equal(data))
+			Expect(configMap.BinaryData).To(Equal(map[string][]byte{"blob": {0, 1, 2}}))
+			Expect(configMap.Annotations).To(HaveKeyWithValue(AnnotationContentHash, ConfigMapDataHash(configMap.Data, configMap.BinaryData)))
+		})
+
+		It("creates a new version if only the binary data changed", func() {
+			Expect(store.CreateWithOptions(ctx, opts(map[string][]byte{"blob": {0, 1, 2}}))).To(Succeed())
+			Expect(IsConfigMapIdenticalError(store.CreateWithOptions(ctx, opts(map[string][]byte{"blob": {0, 1, 2}})))).To(BeTrue())
+			Expect(store.CreateWithOptions(ctx, opts(map[string][]byte{"blob": {3}}))).To(Succeed())
+
+			n, err := store.VersionCount(ctx, namespace, "fake-config")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(n).To(Equal(2))
+		})
+
+		It("rejects keys used for data and binary data", func() {
+			err := store.CreateWithOptions(ctx, opts(map[string][]byte{"config.yml": {0}}))
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("used for data and binary data"))
+		})
+	})
+
+	Describe("SetConfigMapReferences", func() {
+		It("replaces config map references with the latest version", func() {
+			Expect(create(data)).To(Succeed())
+			Expect(create(map[string]string{"config.yml": "foo: baz"})).To(Succeed())
+
+			podSpec := &corev1.PodSpec{
+				Containers: []corev1.Container{
+					{
+						EnvFrom: []corev1.EnvFromSource{
+							{
+								ConfigMapRef: &corev1.ConfigMapEnvSource{
+									LocalObjectReference: corev1.LocalObjectReference{Name: "fake-config-v1"},
+								},
+							},
+						},
+					},
+				},
+				Volumes: []corev1.Volume{
+					{
+						Name: "config",
+						VolumeSource: corev1.VolumeSource{
+							ConfigMap: &corev1.ConfigMapVolumeSource{
+								LocalObjectReference: corev1.LocalObjectReference{Name: "fake-config-v1"},
+							},
+						},
+					},
+				},
+			}
+
+			Expect(store.SetConfigMapReferences(ctx, namespace, podSpec)).To(Succeed())
+
+			configMapsInSpec, _ := GetConfigNamesFromSpec(*podSpec)
+			Expect(configMapsInSpec).To(HaveLen(1))
+			Expect(configMapsInSpec).To(HaveKey("fake-config-v2"))
+		})
+	})
+
+	Describe("Delete", func() {
+		It("removes all versions", func() {
+			Expect(create(data)).To(Succeed())
+			Expect(create(map[string]string{"config.yml": "foo: baz"})).To(Succeed())
+
+			Expect(store.Delete(ctx, namespace, "fake-config")).To(Succeed())
+
+			list, err := clientset.CoreV1().ConfigMaps(namespace).List(ctx, metav1.ListOptions{})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(list.Items).To(BeEmpty())
+		})
+	})
+
+	Describe("Prune", func() {
+		It("keeps the newest versions", func() {
+			Expect(create(data)).To(Succeed())
+			Expect(create(map[string]string{"config.yml": "foo: baz"})).To(Succeed())
+			Expect(create(map[string]string{"config.yml": "foo: qux"})).To(Succeed())
+
+			deleted, err := store.Prune(ctx, namespace, "fake-config", 2)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(deleted).To(ConsistOf("fake-config-v1"))
+
+			list, err := store.List(ctx, namespace, "fake-config")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(list).To(HaveLen(2))
+		})
+	})
+
+	Describe("DecorateVersion", func() {
+		It("records decorations on specific versions", func() {
+			Expect(create(data)).To(Succeed())
+			Expect(create(map[string]string{"config.yml": "foo: baz"})).To(Succeed())
+
+			Expect(store.DecorateVersion(ctx, namespace, "fake-config", 1, DecorationDeployed, "tester")).To(Succeed())
+
+			latest, err := store.LatestDecorated(ctx, namespace, "fake-config", DecorationDeployed)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(latest.Name).To(Equal("fake-config-v1"))
+
+			history, err := store.DecorationHistory(ctx, namespace, "fake-config")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(history).To(HaveLen(1))
+			Expect(history[0].Version).To(Equal(1))
+			Expect(history[0].Actor).To(Equal("tester"))
+
+			_, err = store.LatestDecorated(ctx, namespace, "fake-config", DecorationFailed)
+			Expect(apierrors.IsNotFound(err)).To(BeTrue())
+		})
+	})
+})
@@ -0,0 +1,401 @@
+package versionedsecretstore
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+
+	"code.cloudfoundry.org/quarks-utils/pkg/ctxlog"
+	"code.cloudfoundry.org/quarks-utils/pkg/podref"
+)
+
+// versionedObjectBackend persists the versions of one kind of object, like
+// secrets or config maps
+type versionedObjectBackend interface {
+	// Kind is the value of the LabelSecretKind label of the versions
+	Kind() string
+	// Description names the kind in messages, e.g. `versioned secret`
+	Description() string
+	// Resource is the resource of the versions, used for NotFound errors
+	Resource() schema.GroupResource
+	// RefKind is the kind of pod spec references to the versions
+	RefKind() podref.Kind
+	// Object returns an empty object, which can be used to patch a version
+	Object(namespace string, name string) metav1.Object
+	// IdenticalError returns the error for a new version, which is identical to the latest one
+	IdenticalError(latest metav1.Object) error
+
+	Create(ctx context.Context, object metav1.Object) error
+	Get(ctx context.Context, nn types.NamespacedName) (metav1.Object, error)
+	Patch(ctx context.Context, object metav1.Object, patch []byte) error
+	Delete(ctx context.Context, object metav1.Object) error
+	List(ctx context.Context, namespace string, matchLabels map[string]string) ([]metav1.Object, error)
+}
+
+// versionedObjectStore implements naming, version selection, pruning and
+// decoration of versions for any kind of object. The versioned secret and
+// config map stores add the handling of their data.
+type versionedObjectStore struct {
+	backend versionedObjectBackend
+}
+
+// newVersion describes a version to be created by versionedObjectStore.create
+type newVersion struct {
+	namespace   string
+	name        string
+	labels      map[string]string
+	annotations map[string]string
+	owners      []metav1.OwnerReference
+	// identicalContent returns true if the latest version has the same content as the new one
+	identicalContent func(latest metav1.Object) bool
+	// build returns the new version with the metadata
+	build func(meta metav1.ObjectMeta) (metav1.Object, error)
+}
+
+// setReferences replaces references to outdated versions in the pod spec with the latest version
+func (s versionedObjectStore) setReferences(ctx context.Context, namespace string, podSpec *corev1.PodSpec) error {
+	description := s.backend.Description()
+	refKind := s.backend.RefKind()
+
+	referenced := map[string]struct{}{}
+	podref.Walk(podSpec, func(kind podref.Kind, name *string) {
+		if kind == refKind {
+			referenced[*name] = struct{}{}
+		}
+	})
+
+	var versions []metav1.Object
+	for nameInSpec := range referenced {
+		// If this doesn't look like a version (e.g. <name>-v2), move on
+		if NamePrefix(nameInSpec) == "" {
+			continue
+		}
+
+		if versions == nil {
+			list, err := s.backend.List(ctx, namespace, labels.Set{LabelSecretKind: s.backend.Kind()})
+			if err != nil {
+				return errors.Wrapf(err, "failed to get latest %s %s in namespace %s", description, NamePrefix(nameInSpec), namespace)
+			}
+
+			// Make sure that the objects we're looking at are actual versions
+			versions = []metav1.Object{}
+			for _, object := range list {
+				if object.GetLabels()[LabelSecretKind] == s.backend.Kind() {
+					versions = append(versions, object)
+				}
+			}
+		}
+
+		// If the latest version doesn't exist yet, ignore this reference and move on
+		latest, name := latestVersion(versions, nameInSpec)
+		if latest == nil {
+			ctxlog.Debugf(ctx, "%s %s in namespace %s doesn't exist", description, name, namespace)
+			continue
+		}
+
+		// if the latest version is different than the current version in the spec, replace it
+		if latest.GetName() != nameInSpec {
+			podref.Walk(podSpec, func(kind podref.Kind, name *string) {
+				if kind == refKind && *name == nameInSpec {
+					*name = latest.GetName()
+				}
+			})
+		}
+	}
+
+	return nil
+}
+
+// create creates the next version, unless the latest version has identical
+// content and metadata
+func (s versionedObjectStore) create(ctx context.Context, v newVersion) error {
+	list, err := s.list(ctx, v.namespace, v.name)
+	if err != nil {
+		return err
+	}
+
+	currentVersion, latest, err := greatestObjectVersion(list)
+	if err != nil {
+		return err
+	}
+
+	// Do not create new versions if the content and the labels (except the version label) are identical
+	if latest != nil &&
+		v.identicalContent(latest) &&
+		identicalMetadata(metav1.ObjectMeta{Labels: latest.GetLabels(), Annotations: latest.GetAnnotations()}, v.labels, v.annotations) {
+		return s.backend.IdenticalError(latest)
+	}
+
+	version := currentVersion + 1
+	v.labels[LabelVersion] = strconv.Itoa(version)
+	v.labels[LabelSecretKind] = s.backend.Kind()
+
+	generatedName, err := generateVersionedName(v.name, version)
+	if err != nil {
+		return err
+	}
+
+	object, err := v.build(metav1.ObjectMeta{
+		Name:            generatedName,
+		Namespace:       v.namespace,
+		Labels:          v.labels,
+		Annotations:     v.annotations,
+		OwnerReferences: v.owners,
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.backend.Create(ctx, object)
+}
+
+// get returns a specific version
+func (s versionedObjectStore) get(ctx context.Context, namespace string, name string, version int) (metav1.Object, error) {
+	generatedName, err := generateVersionedName(name, version)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.backend.Get(ctx, types.NamespacedName{Namespace: namespace, Name: generatedName})
+}
+
+// latest returns the latest version
+func (s versionedObjectStore) latest(ctx context.Context, namespace string, name string) (metav1.Object, error) {
+	latestVersion, err := s.greatestVersion(ctx, namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	return s.get(ctx, namespace, name, latestVersion)
+}
+
+// list returns all versions
+func (s versionedObjectStore) list(ctx context.Context, namespace string, name string) ([]metav1.Object, error) {
+	labelsSet := labels.Set{
+		LabelSecretKind: s.backend.Kind(),
+	}
+
+	objects, err := s.backend.List(ctx, namespace, labelsSet)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to list %ss with labels %s", s.backend.Description(), labelsSet.String())
+	}
+
+	result := []metav1.Object{}
+
+	nameRegex := versionedNameRegex(name)
+	for _, object := range objects {
+		if nameRegex.MatchString(object.GetName()) {
+			result = append(result, object)
+		}
+	}
+
+	return result, nil
+}
+
+// count returns the number of versions
+func (s versionedObjectStore) count(ctx context.Context, namespace string, name string) (int, error) {
+	list, err := s.list(ctx, namespace, name)
+	if err != nil {
+		return 0, err
+	}
+
+	return len(list), nil
+}
+
+// decorate adds a label to the latest version. It patches the labels only,
+// so the data of the version stays untouched.
+func (s versionedObjectStore) decorate(ctx context.Context, namespace string, name string, key string, value string) error {
+	version, err := s.greatestVersion(ctx, namespace, name)
+	if err != nil {
+		return err
+	}
+
+	generatedName, err := generateVersionedName(name, version)
+	if err != nil {
+		return err
+	}
+
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"labels": map[string]string{key: value},
+		},
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to build label patch for %s '%s/%s'", s.backend.Description(), namespace, generatedName)
+	}
+
+	return s.backend.Patch(ctx, s.backend.Object(namespace, generatedName), patch)
+}
+
+// decorateVersion records the decoration on a specific version, together
+// with the current time and the actor
+func (s versionedObjectStore) decorateVersion(ctx context.Context, namespace string, name string, version int, decoration Decoration, actor string) error {
+	if err := decoration.validate(); err != nil {
+		return err
+	}
+
+	generatedName, err := generateVersionedName(name, version)
+	if err != nil {
+		return err
+	}
+
+	description := s.backend.Description()
+	record, err := json.Marshal(DecorationRecord{Time: metav1.Now(), Actor: actor})
+	if err != nil {
+		return errors.Wrapf(err, "failed to marshal decoration '%s' for %s '%s/%s'", decoration, description, namespace, generatedName)
+	}
+
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"labels":      map[string]string{decoration.Key(): "true"},
+			"annotations": map[string]string{decoration.Key(): string(record)},
+		},
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to build decoration patch for %s '%s/%s'", description, namespace, generatedName)
+	}
+
+	if err := s.backend.Patch(ctx, s.backend.Object(namespace, generatedName), patch); err != nil {
+		return errors.Wrapf(err, "failed to decorate %s '%s/%s' as '%s'", description, namespace, generatedName, decoration)
+	}
+	return nil
+}
+
+// latestDecorated returns the latest version, which carries the decoration.
+// It returns a NotFound error if no version is decorated.
+func (s versionedObjectStore) latestDecorated(ctx context.Context, namespace string, name string, decoration Decoration) (metav1.Object, error) {
+	list, err := s.list(ctx, namespace, name)
+	if err != nil {
+		return nil, err
+	}
+
+	decorated := []metav1.Object{}
+	for _, object := range list {
+		if object.GetLabels()[decoration.Key()] == "true" {
+			decorated = append(decorated, object)
+		}
+	}
+
+	_, latest, err := greatestObjectVersion(decorated)
+	if err != nil {
+		return nil, err
+	}
+	if latest == nil {
+		return nil, apierrors.NewNotFound(s.backend.Resource(), fmt.Sprintf("%s (decorated as %s)", name, decoration))
+	}
+	return latest, nil
+}
+
+// decorationHistory returns the decorations of all versions, ordered by time
+func (s versionedObjectStore) decorationHistory(ctx context.Context, namespace string, name string) ([]DecorationRecord, error) {
+	list, err := s.list(ctx, namespace, name)
+	if err != nil {
+		return nil, err
+	}
+
+	history := []DecorationRecord{}
+	for _, object := range list {
+		records, err := objectDecorations(object)
+		if err != nil {
+			return nil, err
+		}
+		history = append(history, records...)
+	}
+
+	sortRecords(history)
+	return history, nil
+}
+
+// delete removes all versions
+func (s versionedObjectStore) delete(ctx context.Context, namespace string, name string) error {
+	list, err := s.list(ctx, namespace, name)
+	if err != nil {
+		return err
+	}
+
+	for _, object := range list {
+		if err := s.backend.Delete(ctx, object); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// prune removes all but the newest keep versions and returns the names of
+// the deleted versions. The latest version is never removed.
+func (s versionedObjectStore) prune(ctx context.Context, namespace string, name string, keep int) ([]string, error) {
+	if keep < 1 {
+		keep = 1
+	}
+
+	list, err := s.list(ctx, namespace, name)
+	if err != nil {
+		return nil, err
+	}
+
+	versions := make(map[metav1.Object]int, len(list))
+	for _, object := range list {
+		version, err := objectVersion(object)
+		if err != nil {
+			return nil, err
+		}
+		versions[object] = version
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return versions[list[i]] > versions[list[j]]
+	})
+
+	deleted := []string{}
+	for i := keep; i < len(list); i++ {
+		if err := s.backend.Delete(ctx, list[i]); err != nil {
+			return deleted, errors.Wrapf(err, "failed to delete version '%s/%s'", namespace, list[i].GetName())
+		}
+		deleted = append(deleted, list[i].GetName())
+	}
+
+	return deleted, nil
+}
+
+func (s versionedObjectStore) greatestVersion(ctx context.Context, namespace string, name string) (int, error) {
+	list, err := s.list(ctx, namespace, name)
+	if err != nil {
+		return -1, err
+	}
+
+	version, _, err := greatestObjectVersion(list)
+	return version, err
+}
+
+// objectVersion returns the version of a versioned object from its labels
+func objectVersion(object metav1.Object) (int, error) {
+	return versionFromLabels(object.GetName(), object.GetLabels())
+}
+
+// greatestObjectVersion returns the greatest version and the corresponding object from the list
+func greatestObjectVersion(list []metav1.Object) (int, metav1.Object, error) {
+	var greatestVersion int
+	var latest metav1.Object
+	for _, object := range list {
+		version, err := objectVersion(object)
+		if err != nil {
+			return 0, nil, err
+		}
+
+		if version > greatestVersion {
+			greatestVersion = version
+			latest = object
+		}
+	}
+
+	return greatestVersion, latest, nil
+}
@@ -1,8 +1,7 @@
-// Package versionedsecretstore impements versioned secrets, by appending a version suffix to their name
+// Package versionedsecretstore impements versioned secrets and config maps, by appending a version suffix to their name
 package versionedsecretstore
 
 import (
-	"fmt"
 	"regexp"
 	"strconv"
 	"strings"
@@ -46,10 +45,17 @@ func NamePrefix(name string) string {
 	return name[:n]
 }
 
-// VersionedName returns a secret name with the version appended
+// VersionedName returns a secret name with the version appended. For valid
+// prefixes, it returns the same name as the store: long prefixes are
+// shortened and the version suffix is always kept.
+// Prefixes with invalid characters, e.g. upper case letters, are sanitized
+// instead. The store rejects those prefixes, so it never creates a version
+// with the returned name.
 func VersionedName(namePrefix string, version int) string {
-	proposedName := fmt.Sprintf("%s-v%d", namePrefix, version)
-	return names.SanitizeSubdomain(proposedName)
+	if invalidNameChars.MatchString(namePrefix) {
+		namePrefix = names.SanitizeSubdomain(namePrefix)
+	}
+	return versionedName(namePrefix, version)
 }
 
 // VersionFromName gets version from versioned secret name
@@ -122,14 +128,19 @@ func IsInitialVersion(secret corev1.Secret) bool {
 
 // Version returns the versioned secrets version from the labels
 func Version(secret corev1.Secret) (int, error) {
-	version, ok := secret.Labels[LabelVersion]
+	return versionFromLabels(secret.Name, secret.Labels)
+}
+
+// versionFromLabels returns the version of a versioned secret or config map from its labels
+func versionFromLabels(name string, labels map[string]string) (int, error) {
+	version, ok := labels[LabelVersion]
 	if !ok {
-		return -1, errors.Errorf("secret '%s' has no version label", secret.Name)
+		return -1, errors.Errorf("'%s' has no version label", name)
 	}
 
 	number, err := strconv.Atoi(version)
 	if err != nil {
-		return -1, errors.Wrapf(err, "invalid secret version '%s', is not a number", version)
+		return -1, errors.Wrapf(err, "invalid version '%s', is not a number", version)
 	}
 
 	return number, nil
@@ -2,21 +2,15 @@ package versionedsecretstore
 
 import (
 	"fmt"
-	"reflect"
-	"regexp"
-	"strconv"
 
 	"github.com/pkg/errors"
 	"golang.org/x/net/context"
 	corev1 "k8s.io/api/core/v1"
-	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
-	"code.cloudfoundry.org/quarks-utils/pkg/ctxlog"
 	"code.cloudfoundry.org/quarks-utils/pkg/meltdown"
 	"code.cloudfoundry.org/quarks-utils/pkg/names"
 	"code.cloudfoundry.org/quarks-utils/pkg/pointers"
@@ -61,7 +55,7 @@ func IsSecretIdenticalError(e error) bool {
 type versionedSecretStoreBackend interface {
 	Create(ctx context.Context, secret *corev1.Secret) error
 	Get(ctx context.Context, nn types.NamespacedName) (*corev1.Secret, error)
-	Update(ctx context.Context, secret *corev1.Secret) error
+	Patch(ctx context.Context, secret *corev1.Secret, patch []byte) error
 	Delete(ctx context.Context, secret *corev1.Secret) error
 	List(ctx context.Context, namespace string, matchLabels map[string]string) (*corev1.SecretList, error)
 }
@@ -70,6 +64,12 @@ type versionedSecretStoreBackend interface {
 //
 // Each update to the secret results in a new persisted version.
 // An existing persisted version of a secret cannot be altered or deleted.
+// Versions are created as immutable secrets, decorations only change their
+// labels. Each version carries a hash of its data in the
+// AnnotationContentHash annotation, which is used to detect identical content.
+//
+// If a KeySource is configured, the data of new versions is encrypted and
+// transparently decrypted when reading versions.
 // The deletion of a secret will result in the removal of all persisted version of that secret.
 //
 // The version number is an integer that is incremented with each version of
@@ -81,89 +81,52 @@ type versionedSecretStoreBackend interface {
 type VersionedSecretStore interface {
 	SetSecretReferences(ctx context.Context, namespace string, podSpec *corev1.PodSpec) error
 	Create(ctx context.Context, namespace string, ownerName string, ownerID types.UID, ownerKind string, secretName string, secretData map[string]string, annotations map[string]string, labels map[string]string, sourceDescription string) error
+	CreateWithOwners(ctx context.Context, namespace string, owners []metav1.OwnerReference, secretName string, secretData map[string]string, annotations map[string]string, labels map[string]string, sourceDescription string) error
+	CreateWithOptions(ctx context.Context, opts CreateOptions) error
 	Get(ctx context.Context, namespace string, secretName string, version int) (*corev1.Secret, error)
 	Latest(ctx context.Context, namespace string, secretName string) (*corev1.Secret, error)
 	List(ctx context.Context, namespace string, secretName string) ([]corev1.Secret, error)
 	VersionCount(ctx context.Context, namespace string, secretName string) (int, error)
 	Delete(ctx context.Context, namespace string, secretName string) error
+	Rollback(ctx context.Context, namespace string, secretName string, version int) error
+	Prune(ctx context.Context, namespace string, secretName string, keep int) ([]string, error)
 	Decorate(ctx context.Context, namespace string, secretName string, key string, value string) error
+	DecorateVersion(ctx context.Context, namespace string, secretName string, version int, decoration Decoration, actor string) error
+	LatestDecorated(ctx context.Context, namespace string, secretName string, decoration Decoration) (*corev1.Secret, error)
+	DecorationHistory(ctx context.Context, namespace string, secretName string) ([]DecorationRecord, error)
 }
 
 // VersionedSecretImpl contains the required fields to persist a secret
 type VersionedSecretImpl struct {
-	backend versionedSecretStoreBackend
+	versions versionedObjectStore
+	envelope *envelope
 }
 
 // NewVersionedSecretStore returns a VersionedSecretStore implementation to be used
 // when working with desired secret secrets
 func NewVersionedSecretStore(client client.Client) VersionedSecretImpl {
-	return VersionedSecretImpl{
-		backend: &versionedSecretStoreClientBackend{client: client},
-	}
+	return newVersionedSecretImpl(&versionedSecretStoreClientBackend{client: client})
 }
 
 // NewClientsetVersionedSecretStore returns a VersionedSecretStore using a kubernetes.Clientset backend
 func NewClientsetVersionedSecretStore(clientset kubernetes.Interface) VersionedSecretImpl {
+	return newVersionedSecretImpl(&versionedSecretStoreClientsetBackend{clientset: clientset})
+}
+
+func newVersionedSecretImpl(backend versionedSecretStoreBackend) VersionedSecretImpl {
 	return VersionedSecretImpl{
-		backend: &versionedSecretStoreClientsetBackend{clientset: clientset},
+		versions: versionedObjectStore{backend: secretVersions{backend: backend}},
 	}
 }
 
 // SetSecretReferences update versioned secret references in pod spec
 func (p VersionedSecretImpl) SetSecretReferences(ctx context.Context, namespace string, podSpec *corev1.PodSpec) error {
-	_, secretsInSpec := GetConfigNamesFromSpec(*podSpec)
-	for secretNameInSpec := range secretsInSpec {
-		versionedSecretPrefix := NamePrefix(secretNameInSpec)
-		// If this secret doesn't look like a versioned secret (e.g. <name>-v2), move on
-		if versionedSecretPrefix == "" {
-			continue
-		}
-
-		// We have the current secret name, we have to look and see if there's a new version
-		versionedSecret, err := p.Latest(ctx, namespace, versionedSecretPrefix)
-
-		// If the latest version of the secret doesn't exist yet, ignore this secret and move on
-		// There should be no situation where a version n + 1 exists, and versions 0 through n don't exist
-		if err != nil && apierrors.IsNotFound(err) {
-			ctxlog.Debugf(ctx, "versioned secret %s in namespace %s doesn't exist", versionedSecretPrefix, namespace)
-			continue
-		}
-
-		if err != nil {
-			return errors.Wrapf(err, "failed to get latest versioned secret %s in namespace %s", versionedSecretPrefix, namespace)
-		}
-
-		// Make sure that the secret we're looking at is an actual versioned secret
-		secretLabel := versionedSecret.Labels
-		if secretLabel == nil {
-			continue
-		}
-
-		secretKind, ok := secretLabel[LabelSecretKind]
-		if !ok || secretKind != VersionSecretKind {
-			continue
-		}
-
-		// if the latest version is different than the current version in the spec, replace it
-		if versionedSecret.Name != secretNameInSpec {
-			replaceVolumesSecretRef(
-				podSpec.Volumes,
-				secretNameInSpec,
-				versionedSecret.GetName(),
-			)
-
-			replaceContainerEnvsSecretRef(
-				podSpec.Containers,
-				secretNameInSpec,
-				versionedSecret.GetName(),
-			)
-		}
-	}
-
-	return nil
+	return p.versions.setReferences(ctx, namespace, podSpec)
 }
 
-// Create creates a new version of the secret from secret data
+// Create creates a new version of the secret from secret data.
+// The owner is referenced as a controller in the quarks API group, use
+// CreateWithOwners for other owners.
 func (p VersionedSecretImpl) Create(ctx context.Context,
 	namespace string,
 	ownerName string,
@@ -175,106 +138,100 @@ func (p VersionedSecretImpl) Create(ctx context.Context,
 	labels map[string]string,
 	sourceDescription string) error {
 
-	if annotations == nil {
-		annotations = map[string]string{}
-	}
-	annotations[AnnotationSourceDescription] = sourceDescription
-
-	latest, err := p.Latest(ctx, namespace, secretName)
-
-	if err == nil {
-		labelsIdentical := true
-		for k, v := range latest.Labels {
-			if k == LabelVersion || k == LabelSecretKind {
-				continue
-			}
-			if labels[k] != v {
-				labelsIdentical = false
-				break
-			}
-		}
+	owners := []metav1.OwnerReference{controllerOwnerReference(ownerName, ownerID, ownerKind)}
+	return p.CreateWithOwners(ctx, namespace, owners, secretName, secretData, annotations, labels, sourceDescription)
+}
 
-		annotationsIdentical := true
-		for k, v := range latest.Annotations {
-			if k == meltdown.AnnotationLastReconcile {
-				continue
-			}
-			if annotations[k] != v {
-				annotationsIdentical = false
-				break
-			}
-		}
+// CreateWithOwners creates a new version of the secret from secret data,
+// referencing all of the given owners. At most one of them may be a
+// controller, see NewOwnerReference.
+func (p VersionedSecretImpl) CreateWithOwners(ctx context.Context,
+	namespace string,
+	owners []metav1.OwnerReference,
+	secretName string,
+	secretData map[string]string,
+	annotations map[string]string,
+	labels map[string]string,
+	sourceDescription string) error {
 
-		encodedData := make(map[string][]byte)
-		for k, v := range secretData {
-			encodedData[k] = []byte(v)
-		}
+	return p.CreateWithOptions(ctx, CreateOptions{
+		Namespace:         namespace,
+		Name:              secretName,
+		StringData:        secretData,
+		Labels:            labels,
+		Annotations:       annotations,
+		Owners:            owners,
+		SourceDescription: sourceDescription,
+	})
+}
 
-		if reflect.DeepEqual(encodedData, latest.Data) && labelsIdentical && annotationsIdentical {
-			// Do not create new versions if the content and the labels (except the version label) are identical
-			return SecretIdenticalError{secret: latest}
-		}
-	}
+// CreateWithOptions creates a new version of the secret
+func (p VersionedSecretImpl) CreateWithOptions(ctx context.Context, opts CreateOptions) error {
+	namespace, secretName := opts.Namespace, opts.Name
 
-	currentVersion, err := p.getGreatestVersion(ctx, namespace, secretName)
-	if err != nil {
-		return err
+	if err := validateOwners(opts.Owners); err != nil {
+		return errors.Wrapf(err, "invalid owners for versioned secret '%s/%s'", namespace, secretName)
 	}
 
-	version := currentVersion + 1
-	labels[LabelVersion] = strconv.Itoa(version)
-	labels[LabelSecretKind] = VersionSecretKind
+	secretType := opts.secretType()
+	data := opts.data()
+	labels, annotations := opts.metadata()
 
-	generatedSecretName, err := generateSecretName(secretName, version)
-	if err != nil {
-		return err
+	// Encrypted versions get a keyed content hash, when they are sealed
+	contentHash := ContentHash(data)
+	if p.envelope == nil {
+		annotations[AnnotationContentHash] = contentHash
 	}
 
-	secret := &corev1.Secret{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:        generatedSecretName,
-			Namespace:   namespace,
-			Labels:      labels,
-			Annotations: annotations,
-			OwnerReferences: []metav1.OwnerReference{
-				{
-					APIVersion:         LabelAPIVersion,
-					Kind:               ownerKind,
-					Name:               ownerName,
-					UID:                ownerID,
-					BlockOwnerDeletion: pointers.Bool(false),
-					Controller:         pointers.Bool(true),
-				},
-			},
+	return p.versions.create(ctx, newVersion{
+		namespace:   namespace,
+		name:        secretName,
+		labels:      labels,
+		annotations: annotations,
+		owners:      opts.Owners,
+		identicalContent: func(latest metav1.Object) bool {
+			secret := latest.(*corev1.Secret)
+			return p.identicalContent(ctx, *secret, data, contentHash) && normalizeType(secret.Type) == secretType
 		},
-		StringData: secretData,
-	}
+		build: func(meta metav1.ObjectMeta) (metav1.Object, error) {
+			secret := &corev1.Secret{
+				ObjectMeta: meta,
+				Type:       secretType,
+				Data:       data,
+				// Clusters without support for immutable secrets drop this field
+				Immutable: pointers.Bool(true),
+			}
 
-	return p.backend.Create(ctx, secret)
+			if p.envelope != nil {
+				encrypted, wrappedKey, encryptedHash, err := p.envelope.seal(ctx, data)
+				if err != nil {
+					return nil, errors.Wrapf(err, "failed to encrypt versioned secret '%s/%s'", namespace, meta.Name)
+				}
+				secret.Data = encrypted
+				secret.Annotations[AnnotationEncryptionKey] = wrappedKey
+				secret.Annotations[AnnotationContentHash] = encryptedHash
+			}
+			return secret, nil
+		},
+	})
 }
 
 // Get returns a specific version of the secret
 func (p VersionedSecretImpl) Get(ctx context.Context, namespace string, deploymentName string, version int) (*corev1.Secret, error) {
-	name, err := generateSecretName(deploymentName, version)
+	object, err := p.versions.get(ctx, namespace, deploymentName, version)
 	if err != nil {
 		return nil, err
 	}
-
-	secret, err := p.backend.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name})
-	if err != nil {
-		return nil, err
-	}
-
-	return secret, nil
+	return p.decrypted(ctx, object)
 }
 
 // Latest returns the latest version of the secret
 func (p VersionedSecretImpl) Latest(ctx context.Context, namespace string, secretName string) (*corev1.Secret, error) {
-	latestVersion, err := p.getGreatestVersion(ctx, namespace, secretName)
+	object, err := p.versions.latest(ctx, namespace, secretName)
 	if err != nil {
 		return nil, err
 	}
-	return p.Get(ctx, namespace, secretName, latestVersion)
+	return p.decrypted(ctx, object)
 }
 
 // List returns all versions of the secret
@@ -284,155 +241,143 @@ func (p VersionedSecretImpl) List(ctx context.Context, namespace string, secretN
 		return nil, err
 	}
 
+	for i := range secrets {
+		if err := p.decrypt(ctx, &secrets[i]); err != nil {
+			return nil, err
+		}
+	}
+
 	return secrets, nil
 }
 
 // VersionCount returns the number of versions for this secret
 func (p VersionedSecretImpl) VersionCount(ctx context.Context, namespace string, secretName string) (int, error) {
-	list, err := p.listSecrets(ctx, namespace, secretName)
-	if err != nil {
-		return 0, err
-	}
-
-	return len(list), nil
+	return p.versions.count(ctx, namespace, secretName)
 }
 
-// Decorate adds a label to the latest version of the secret
+// Decorate adds a label to the latest version of the secret.
+// It patches the labels only, so the data of the version stays untouched.
+// Use DecorateVersion to record a typed status on a specific version.
 func (p VersionedSecretImpl) Decorate(ctx context.Context, namespace string, secretName string, key string, value string) error {
-	version, err := p.getGreatestVersion(ctx, namespace, secretName)
-	if err != nil {
-		return err
-	}
-
-	generatedSecretName, err := generateSecretName(secretName, version)
-	if err != nil {
-		return err
-	}
-
-	secret, err := p.backend.Get(ctx, client.ObjectKey{Namespace: namespace, Name: generatedSecretName})
-	if err != nil {
-		return err
-	}
-
-	labels := secret.GetLabels()
-	if labels == nil {
-		labels = make(map[string]string)
-	}
-
-	labels[key] = value
-	secret.SetLabels(labels)
-
-	return p.backend.Update(ctx, secret)
+	return p.versions.decorate(ctx, namespace, secretName, key, value)
 }
 
 // Delete removes all versions of the secret and therefore the
 // secret itself.
 func (p VersionedSecretImpl) Delete(ctx context.Context, namespace string, secretName string) error {
-	list, err := p.listSecrets(ctx, namespace, secretName)
-	if err != nil {
-		return err
-	}
-
-	for _, secret := range list {
-		if err := p.backend.Delete(ctx, &secret); err != nil {
-			return err
-		}
-	}
-
-	return nil
+	return p.versions.delete(ctx, namespace, secretName)
 }
 
-func (p VersionedSecretImpl) listSecrets(ctx context.Context, namespace string, secretName string) ([]corev1.Secret, error) {
-	secretLabelsSet := labels.Set{
-		LabelSecretKind: VersionSecretKind,
+// Rollback creates a new version of the secret, with the data, type, labels,
+// annotations and owners of the given version. It returns a
+// SecretIdenticalError if the latest version already has the same content.
+func (p VersionedSecretImpl) Rollback(ctx context.Context, namespace string, secretName string, version int) error {
+	old, err := p.Get(ctx, namespace, secretName, version)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get version %d of versioned secret '%s/%s'", version, namespace, secretName)
 	}
 
-	secrets, err := p.backend.List(ctx, namespace, secretLabelsSet)
+	latest, err := p.Latest(ctx, namespace, secretName)
 	if err != nil {
-		return nil, errors.Wrapf(err, "Failed to list secrets with labels %s", secretLabelsSet.String())
+		return errors.Wrapf(err, "failed to get latest version of versioned secret '%s/%s'", namespace, secretName)
 	}
-
-	result := []corev1.Secret{}
-
-	nameRegex := regexp.MustCompile(fmt.Sprintf(`^%s-v\d+$`, secretName))
-	for _, secret := range secrets.Items {
-		if nameRegex.MatchString(secret.Name) {
-			result = append(result, secret)
-		}
+	if ContentHash(latest.Data) == ContentHash(old.Data) && normalizeType(latest.Type) == normalizeType(old.Type) {
+		return SecretIdenticalError{secret: latest}
 	}
 
-	return result, nil
-}
+	labels := copyMap(old.Labels)
+	delete(labels, LabelVersion)
+	delete(labels, LabelSecretKind)
 
-func (p VersionedSecretImpl) getGreatestVersion(ctx context.Context, namespace string, secretName string) (int, error) {
-	list, err := p.listSecrets(ctx, namespace, secretName)
-	if err != nil {
-		return -1, err
-	}
+	annotations := copyMap(old.Annotations)
+	delete(annotations, AnnotationContentHash)
+	delete(annotations, AnnotationEncryptionKey)
+	delete(annotations, AnnotationSourceDescription)
 
-	var greatestVersion int
-	for _, secret := range list {
-		version, err := Version(secret)
-		if err != nil {
-			return 0, err
+	// Decorations describe the old version, not the new one
+	for k := range labels {
+		if isDecorationKey(k) {
+			delete(labels, k)
 		}
-
-		if version > greatestVersion {
-			greatestVersion = version
+	}
+	for k := range annotations {
+		if isDecorationKey(k) {
+			delete(annotations, k)
 		}
 	}
 
-	return greatestVersion, nil
+	return p.CreateWithOptions(ctx, CreateOptions{
+		Namespace:         namespace,
+		Name:              secretName,
+		Data:              old.Data,
+		Type:              old.Type,
+		Labels:            labels,
+		Annotations:       annotations,
+		Owners:            old.OwnerReferences,
+		SourceDescription: fmt.Sprintf("rollback to version %d", version),
+	})
 }
 
-// generateSecretName creates the name of a versioned secret and errors if it's invalid
-func generateSecretName(namePrefix string, version int) (string, error) {
-	proposedName := fmt.Sprintf("%s-v%d", namePrefix, version)
+// Prune removes all but the newest keep versions of the secret and returns
+// the names of the deleted versions. The latest version is never removed.
+func (p VersionedSecretImpl) Prune(ctx context.Context, namespace string, secretName string, keep int) ([]string, error) {
+	return p.versions.prune(ctx, namespace, secretName, keep)
+}
 
-	// Check for Kubernetes name requirements (length)
-	const maxChars = 253
-	if len(proposedName) > maxChars {
-		return "", errors.Errorf("secret name exceeds maximum number of allowed characters (actual=%d, allowed=%d)", len(proposedName), maxChars)
+// listSecrets returns all versions of the secret, without decrypting them
+func (p VersionedSecretImpl) listSecrets(ctx context.Context, namespace string, secretName string) ([]corev1.Secret, error) {
+	list, err := p.versions.list(ctx, namespace, secretName)
+	if err != nil {
+		return nil, err
 	}
 
-	// Check for Kubernetes name requirements (characters)
-	if re := regexp.MustCompile(`[^a-z0-9.-]`); re.MatchString(proposedName) {
-		return "", errors.Errorf("secret name contains invalid characters, only lower case, dot and dash are allowed")
+	secrets := make([]corev1.Secret, len(list))
+	for i, object := range list {
+		secrets[i] = *object.(*corev1.Secret)
 	}
-
-	return proposedName, nil
+	return secrets, nil
 }
 
-// replaceVolumesSecretRef replace secret reference of volumes
-func replaceVolumesSecretRef(volumes []corev1.Volume, secretName string, versionedSecretName string) {
-	for _, vol := range volumes {
-		if vol.VolumeSource.Secret != nil && vol.VolumeSource.Secret.SecretName == secretName {
-			vol.VolumeSource.Secret.SecretName = versionedSecretName
-		}
+// decrypted returns the version as a secret with decrypted data
+func (p VersionedSecretImpl) decrypted(ctx context.Context, object metav1.Object) (*corev1.Secret, error) {
+	secret := object.(*corev1.Secret)
+	if err := p.decrypt(ctx, secret); err != nil {
+		return nil, err
 	}
+	return secret, nil
 }
 
-// replaceContainerEnvsSecretRef replace secret reference of envs for each container
-func replaceContainerEnvsSecretRef(containers []corev1.Container, secretName string, versionedSecretName string) {
-	for _, container := range containers {
+// identicalContent returns true if the data of the latest version has the
+// content hash of the new data
+func (p VersionedSecretImpl) identicalContent(ctx context.Context, latest corev1.Secret, data map[string][]byte, contentHash string) bool {
+	if _, ok := latest.Annotations[AnnotationEncryptionKey]; ok && p.envelope != nil {
+		return p.envelope.identicalContent(ctx, latest, data)
+	}
+	return SecretContentHash(latest) == contentHash
+}
 
-		for _, env := range container.EnvFrom {
-			if s := env.SecretRef; s != nil {
-				if s.Name == secretName {
-					s.Name = versionedSecretName
-				}
-			}
+// identicalMetadata returns true if the labels and annotations of the latest
+// version are identical to the new ones, ignoring version, decoration and
+// reconcile bookkeeping
+func identicalMetadata(latest metav1.ObjectMeta, labels map[string]string, annotations map[string]string) bool {
+	for k, v := range latest.Labels {
+		if k == LabelVersion || k == LabelSecretKind || isDecorationKey(k) {
+			continue
+		}
+		if labels[k] != v {
+			return false
 		}
+	}
 
-		for _, env := range container.Env {
-			if env.ValueFrom == nil {
-				continue
-			}
-			if sRef := env.ValueFrom.SecretKeyRef; sRef != nil {
-				if sRef.Name == secretName {
-					sRef.Name = versionedSecretName
-				}
-			}
+	for k, v := range latest.Annotations {
+		// The content hash is compared separately, it depends on the key of encrypted versions
+		if k == meltdown.AnnotationLastReconcile || k == AnnotationEncryptionKey || k == AnnotationContentHash || isDecorationKey(k) {
+			continue
+		}
+		if annotations[k] != v {
+			return false
 		}
 	}
+
+	return true
 }
@@ -6,9 +6,12 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"code.cloudfoundry.org/quarks-utils/pkg/podref"
 )
 
 type versionedSecretStoreClientsetBackend struct {
@@ -24,8 +27,8 @@ func (b *versionedSecretStoreClientsetBackend) Get(ctx context.Context, nn types
 	return b.clientset.CoreV1().Secrets(nn.Namespace).Get(ctx, nn.Name, metav1.GetOptions{})
 }
 
-func (b *versionedSecretStoreClientsetBackend) Update(ctx context.Context, secret *corev1.Secret) error {
-	_, err := b.clientset.CoreV1().Secrets(secret.Namespace).Update(ctx, secret, metav1.UpdateOptions{})
+func (b *versionedSecretStoreClientsetBackend) Patch(ctx context.Context, secret *corev1.Secret, patch []byte) error {
+	_, err := b.clientset.CoreV1().Secrets(secret.Namespace).Patch(ctx, secret.Name, types.MergePatchType, patch, metav1.PatchOptions{})
 	return err
 }
 
@@ -42,6 +45,12 @@ func (b *versionedSecretStoreClientsetBackend) List(ctx context.Context, namespa
 	return secrets, err
 }
 
+func (b *versionedSecretStoreClientsetBackend) ListNamespaces(ctx context.Context, matchLabels map[string]string) (*corev1.NamespaceList, error) {
+	return b.clientset.CoreV1().Namespaces().List(ctx, metav1.ListOptions{
+		LabelSelector: labels.Set(matchLabels).String(),
+	})
+}
+
 type versionedSecretStoreClientBackend struct {
 	client client.Client
 }
@@ -59,8 +68,8 @@ func (b *versionedSecretStoreClientBackend) Get(ctx context.Context, nn types.Na
 	return secret, nil
 }
 
-func (b *versionedSecretStoreClientBackend) Update(ctx context.Context, secret *corev1.Secret) error {
-	return b.client.Update(ctx, secret)
+func (b *versionedSecretStoreClientBackend) Patch(ctx context.Context, secret *corev1.Secret, patch []byte) error {
+	return b.client.Patch(ctx, secret, client.RawPatch(types.MergePatchType, patch))
 }
 
 func (b *versionedSecretStoreClientBackend) Delete(ctx context.Context, secret *corev1.Secret) error {
@@ -78,3 +87,177 @@ func (b *versionedSecretStoreClientBackend) List(ctx context.Context, namespace
 	)
 	return secrets, err
 }
+
+func (b *versionedSecretStoreClientBackend) ListNamespaces(ctx context.Context, matchLabels map[string]string) (*corev1.NamespaceList, error) {
+	namespaces := &corev1.NamespaceList{}
+
+	err := b.client.List(
+		ctx,
+		namespaces,
+		client.MatchingLabels(matchLabels),
+	)
+	return namespaces, err
+}
+
+type versionedConfigMapStoreClientsetBackend struct {
+	clientset kubernetes.Interface
+}
+
+func (b *versionedConfigMapStoreClientsetBackend) Create(ctx context.Context, configMap *corev1.ConfigMap) error {
+	_, err := b.clientset.CoreV1().ConfigMaps(configMap.Namespace).Create(ctx, configMap, metav1.CreateOptions{})
+	return err
+}
+
+func (b *versionedConfigMapStoreClientsetBackend) Get(ctx context.Context, nn types.NamespacedName) (*corev1.ConfigMap, error) {
+	return b.clientset.CoreV1().ConfigMaps(nn.Namespace).Get(ctx, nn.Name, metav1.GetOptions{})
+}
+
+func (b *versionedConfigMapStoreClientsetBackend) Patch(ctx context.Context, configMap *corev1.ConfigMap, patch []byte) error {
+	_, err := b.clientset.CoreV1().ConfigMaps(configMap.Namespace).Patch(ctx, configMap.Name, types.MergePatchType, patch, metav1.PatchOptions{})
+	return err
+}
+
+func (b *versionedConfigMapStoreClientsetBackend) Delete(ctx context.Context, configMap *corev1.ConfigMap) error {
+	return b.clientset.CoreV1().ConfigMaps(configMap.Namespace).Delete(ctx, configMap.Name, metav1.DeleteOptions{})
+}
+
+func (b *versionedConfigMapStoreClientsetBackend) List(ctx context.Context, namespace string, matchLabels map[string]string) (*corev1.ConfigMapList, error) {
+	return b.clientset.CoreV1().ConfigMaps(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: labels.Set(matchLabels).String(),
+	})
+}
+
+type versionedConfigMapStoreClientBackend struct {
+	client client.Client
+}
+
+func (b *versionedConfigMapStoreClientBackend) Create(ctx context.Context, configMap *corev1.ConfigMap) error {
+	return b.client.Create(ctx, configMap)
+}
+
+func (b *versionedConfigMapStoreClientBackend) Get(ctx context.Context, nn types.NamespacedName) (*corev1.ConfigMap, error) {
+	configMap := &corev1.ConfigMap{}
+	err := b.client.Get(ctx, nn, configMap)
+	if err != nil {
+		return nil, err
+	}
+	return configMap, nil
+}
+
+func (b *versionedConfigMapStoreClientBackend) Patch(ctx context.Context, configMap *corev1.ConfigMap, patch []byte) error {
+	return b.client.Patch(ctx, configMap, client.RawPatch(types.MergePatchType, patch))
+}
+
+func (b *versionedConfigMapStoreClientBackend) Delete(ctx context.Context, configMap *corev1.ConfigMap) error {
+	return b.client.Delete(ctx, configMap)
+}
+
+func (b *versionedConfigMapStoreClientBackend) List(ctx context.Context, namespace string, matchLabels map[string]string) (*corev1.ConfigMapList, error) {
+	configMaps := &corev1.ConfigMapList{}
+
+	err := b.client.List(
+		ctx,
+		configMaps,
+		client.InNamespace(namespace),
+		client.MatchingLabels(matchLabels),
+	)
+	return configMaps, err
+}
+
+// secretVersions adapts a versionedSecretStoreBackend to the versioned object store
+type secretVersions struct {
+	backend versionedSecretStoreBackend
+}
+
+var _ versionedObjectBackend = secretVersions{}
+
+func (secretVersions) Kind() string                   { return VersionSecretKind }
+func (secretVersions) Description() string            { return "versioned secret" }
+func (secretVersions) Resource() schema.GroupResource { return corev1.Resource("secrets") }
+func (secretVersions) RefKind() podref.Kind           { return podref.KindSecret }
+
+func (secretVersions) Object(namespace string, name string) metav1.Object {
+	return &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name}}
+}
+
+func (secretVersions) IdenticalError(latest metav1.Object) error {
+	return SecretIdenticalError{secret: latest.(*corev1.Secret)}
+}
+
+func (b secretVersions) Create(ctx context.Context, object metav1.Object) error {
+	return b.backend.Create(ctx, object.(*corev1.Secret))
+}
+
+func (b secretVersions) Get(ctx context.Context, nn types.NamespacedName) (metav1.Object, error) {
+	return b.backend.Get(ctx, nn)
+}
+
+func (b secretVersions) Patch(ctx context.Context, object metav1.Object, patch []byte) error {
+	return b.backend.Patch(ctx, object.(*corev1.Secret), patch)
+}
+
+func (b secretVersions) Delete(ctx context.Context, object metav1.Object) error {
+	return b.backend.Delete(ctx, object.(*corev1.Secret))
+}
+
+func (b secretVersions) List(ctx context.Context, namespace string, matchLabels map[string]string) ([]metav1.Object, error) {
+	list, err := b.backend.List(ctx, namespace, matchLabels)
+	if err != nil {
+		return nil, err
+	}
+
+	objects := make([]metav1.Object, len(list.Items))
+	for i := range list.Items {
+		objects[i] = &list.Items[i]
+	}
+	return objects, nil
+}
+
+// configMapVersions adapts a versionedConfigMapStoreBackend to the versioned object store
+type configMapVersions struct {
+	backend versionedConfigMapStoreBackend
+}
+
+var _ versionedObjectBackend = configMapVersions{}
+
+func (configMapVersions) Kind() string                   { return VersionConfigMapKind }
+func (configMapVersions) Description() string            { return "versioned config map" }
+func (configMapVersions) Resource() schema.GroupResource { return corev1.Resource("configmaps") }
+func (configMapVersions) RefKind() podref.Kind           { return podref.KindConfigMap }
+
+func (configMapVersions) Object(namespace string, name string) metav1.Object {
+	return &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name}}
+}
+
+func (configMapVersions) IdenticalError(latest metav1.Object) error {
+	return ConfigMapIdenticalError{configMap: latest.(*corev1.ConfigMap)}
+}
+
+func (b configMapVersions) Create(ctx context.Context, object metav1.Object) error {
+	return b.backend.Create(ctx, object.(*corev1.ConfigMap))
+}
+
+func (b configMapVersions) Get(ctx context.Context, nn types.NamespacedName) (metav1.Object, error) {
+	return b.backend.Get(ctx, nn)
+}
+
+func (b configMapVersions) Patch(ctx context.Context, object metav1.Object, patch []byte) error {
+	return b.backend.Patch(ctx, object.(*corev1.ConfigMap), patch)
+}
+
+func (b configMapVersions) Delete(ctx context.Context, object metav1.Object) error {
+	return b.backend.Delete(ctx, object.(*corev1.ConfigMap))
+}
+
+func (b configMapVersions) List(ctx context.Context, namespace string, matchLabels map[string]string) ([]metav1.Object, error) {
+	list, err := b.backend.List(ctx, namespace, matchLabels)
+	if err != nil {
+		return nil, err
+	}
+
+	objects := make([]metav1.Object, len(list.Items))
+	for i := range list.Items {
+		objects[i] = &list.Items[i]
+	}
+	return objects, nil
+}
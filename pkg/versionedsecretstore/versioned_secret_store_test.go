@@ -225,6 +225,76 @@ name: fake-deployment-v4
 				Expect(secretsInSpec).To(HaveKey(secretV2.Name))
 			})
 
+			It("should replace references in init containers, projected volumes, CSI volumes and image pull secrets", func() {
+				podSpec.InitContainers = []corev1.Container{
+					{
+						EnvFrom: []corev1.EnvFromSource{
+							{
+								SecretRef: &corev1.SecretEnvSource{
+									LocalObjectReference: corev1.LocalObjectReference{Name: secretV1.GetName()},
+								},
+							},
+						},
+					},
+				}
+				podSpec.Volumes = append(podSpec.Volumes,
+					corev1.Volume{
+						Name: "projected-volume",
+						VolumeSource: corev1.VolumeSource{
+							Projected: &corev1.ProjectedVolumeSource{
+								Sources: []corev1.VolumeProjection{
+									{
+										Secret: &corev1.SecretProjection{
+											LocalObjectReference: corev1.LocalObjectReference{Name: secretV1.GetName()},
+										},
+									},
+								},
+							},
+						},
+					},
+					corev1.Volume{
+						Name: "csi-volume",
+						VolumeSource: corev1.VolumeSource{
+							CSI: &corev1.CSIVolumeSource{
+								NodePublishSecretRef: &corev1.LocalObjectReference{Name: secretV1.GetName()},
+							},
+						},
+					},
+				)
+				podSpec.ImagePullSecrets = []corev1.LocalObjectReference{{Name: secretV1.GetName()}}
+
+				client.GetCalls(func(_ context.Context, nn types.NamespacedName, object crc.Object) error {
+					switch object := object.(type) {
+					case *corev1.Secret:
+						if nn.Name == secretV2.GetName() {
+							secretV2.DeepCopyInto(object)
+							return nil
+						}
+					}
+
+					return apierrors.NewNotFound(schema.GroupResource{}, nn.Name)
+				})
+				client.ListCalls(func(_ context.Context, object crc.ObjectList, _ ...crc.ListOption) error {
+					switch object := object.(type) {
+					case *corev1.SecretList:
+						object.Items = []corev1.Secret{*secretV1, *secretV2}
+					}
+					return nil
+				})
+
+				err := store.SetSecretReferences(ctx, namespace, podSpec)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(podSpec.InitContainers[0].EnvFrom[0].SecretRef.Name).To(Equal(secretV2.GetName()))
+				Expect(podSpec.Volumes[1].Projected.Sources[0].Secret.Name).To(Equal(secretV2.GetName()))
+				Expect(podSpec.Volumes[2].CSI.NodePublishSecretRef.Name).To(Equal(secretV2.GetName()))
+				Expect(podSpec.ImagePullSecrets[0].Name).To(Equal(secretV2.GetName()))
+
+				_, secretsInSpec := GetConfigNamesFromSpec(*podSpec)
+				Expect(secretsInSpec).To(HaveLen(1))
+				Expect(secretsInSpec).To(HaveKey(secretV2.GetName()))
+			})
+
 			It("should return error if it fails in getting latest versioned secret", func() {
 				podSpec.Containers[0].Env[0].ValueFrom.SecretKeyRef.Name = secretV1.GetName()
 				podSpec.Containers[0].EnvFrom[0].SecretRef.Name = secretV1.GetName()
@@ -260,6 +330,10 @@ name: fake-deployment-v4
 						Expect(object.GetName()).To(Equal(fmt.Sprintf("%s-v%d", secretNamePrefix, 1)))
 						Expect(object.GetOwnerReferences()[0].Name).To(Equal("some-owner"))
 						Expect(object.GetOwnerReferences()[0].Kind).To(Equal("some-kind"))
+						Expect(*object.Immutable).To(BeTrue())
+						Expect(object.GetAnnotations()).To(HaveKeyWithValue(AnnotationContentHash, ContentHash(map[string][]byte{
+							"manifest": []byte(`{"instance_groups":[{"instances":3,"name":"diego"},{"instances":2,"name":"mysql"}]}`),
+						})))
 						return nil
 					}
 					return nil
@@ -366,8 +440,54 @@ name: fake-deployment-v4
 			})
 		})
 
+		Context("when the latest version has a content hash annotation", func() {
+			It("should use the hash to detect identical content", func() {
+				data := map[string]string{"manifest": "foo"}
+				secretV1.Annotations = map[string]string{
+					AnnotationSourceDescription: exampleSourceDescription,
+					AnnotationContentHash:       ContentHash(map[string][]byte{"manifest": []byte("foo")}),
+				}
+				// the hash is authoritative, data is not compared
+				secretV1.Data = nil
+
+				client.ListCalls(func(_ context.Context, object crc.ObjectList, _ ...crc.ListOption) error {
+					switch list := object.(type) {
+					case *corev1.SecretList:
+						list.Items = []corev1.Secret{*secretV1}
+					}
+					return nil
+				})
+
+				err := store.Create(
+					ctx,
+					namespace,
+					"some-owner",
+					types.UID("d3d423b7-a57f-43b0-8305-79d484154e4f"),
+					"some-kind",
+					secretNamePrefix,
+					data,
+					nil,
+					map[string]string{},
+					exampleSourceDescription,
+				)
+				Expect(IsSecretIdenticalError(err)).To(BeTrue())
+				Expect(client.GetCallCount()).To(Equal(0))
+				Expect(client.CreateCallCount()).To(Equal(0))
+			})
+		})
+
 		Context("when the deployment name exceeds a length of 253 characters", func() {
-			It("should fail to create a new version", func() {
+			It("should create a new version with a shortened name", func() {
+				longName := strings.Repeat("foobar", 42)
+				client.CreateCalls(func(_ context.Context, object crc.Object, _ ...crc.CreateOption) error {
+					Expect(len(object.GetName())).To(BeNumerically("<=", 253))
+					Expect(object.GetName()).To(HaveSuffix("-v1"))
+					Expect(object.GetName()).To(Equal(VersionedName(longName, 1)))
+					Expect(object.GetAnnotations()).To(HaveKeyWithValue(AnnotationNamePrefix, longName))
+					Expect(UnversionedName(object)).To(Equal(longName))
+					return nil
+				})
+
 				store = NewVersionedSecretStore(client)
 				err := store.Create(
 					ctx,
@@ -375,7 +495,7 @@ name: fake-deployment-v4
 					"some-owner",
 					types.UID("d3d423b7-a57f-43b0-8305-79d484154e4f"),
 					"some-kind",
-					strings.Repeat("foobar", 42),
+					longName,
 					map[string]string{
 						"manifest": `{"instance_groups":[{"instances":3,"name":"diego"},{"instances":2,"name":"mysql"}]}`,
 					},
@@ -383,7 +503,8 @@ name: fake-deployment-v4
 					secretLabels,
 					exampleSourceDescription,
 				)
-				Expect(err).To(HaveOccurred())
+				Expect(err).ToNot(HaveOccurred())
+				Expect(client.CreateCallCount()).To(Equal(1))
 			})
 		})
 	})
@@ -445,10 +566,14 @@ name: fake-deployment-v4
 					return apierrors.NewNotFound(schema.GroupResource{}, nn.Name)
 				})
 
-				client.UpdateCalls(func(_ context.Context, object crc.Object, _ ...crc.UpdateOption) error {
+				client.PatchCalls(func(_ context.Context, object crc.Object, patch crc.Patch, _ ...crc.PatchOption) error {
 					switch object := object.(type) {
 					case *corev1.Secret:
-						Expect(object.Labels).To(HaveKeyWithValue("foo", "bar"))
+						Expect(object.GetName()).To(Equal(secretV1.GetName()))
+						Expect(patch.Type()).To(Equal(types.MergePatchType))
+						data, err := patch.Data(object)
+						Expect(err).ToNot(HaveOccurred())
+						Expect(string(data)).To(Equal(`{"metadata":{"labels":{"foo":"bar"}}}`))
 						return nil
 					}
 
@@ -457,6 +582,8 @@ name: fake-deployment-v4
 
 				err := store.Decorate(ctx, namespace, secretNamePrefix, "foo", "bar")
 				Expect(err).ToNot(HaveOccurred())
+				Expect(client.PatchCallCount()).To(Equal(1))
+				Expect(client.UpdateCallCount()).To(Equal(0))
 			})
 		})
 	})
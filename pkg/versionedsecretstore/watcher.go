@@ -0,0 +1,320 @@
+package versionedsecretstore
+
+import (
+	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	toolscache "k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+)
+
+// VersionEvent is delivered to subscribers, when a new version of a versioned secret appears
+type VersionEvent struct {
+	// Namespace of the versioned secret
+	Namespace string
+	// Name of the versioned secret, without the version suffix
+	Name string
+	// Version is the new latest version
+	Version int
+	// Secret is the new version
+	Secret *corev1.Secret
+}
+
+// Informer is implemented by client-go shared informers and by the informers
+// of a controller-runtime cache
+type Informer interface {
+	AddEventHandler(handler toolscache.ResourceEventHandler)
+}
+
+type subscription struct {
+	namespace string
+	name      string
+	events    chan VersionEvent
+	done      <-chan struct{}
+
+	mu      sync.Mutex
+	pending []VersionEvent
+	wake    chan struct{}
+}
+
+// push queues the event without blocking
+func (s *subscription) push(e VersionEvent) {
+	s.mu.Lock()
+	s.pending = append(s.pending, e)
+	s.mu.Unlock()
+
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// run delivers the queued events in order, until the subscription is done
+func (s *subscription) run() {
+	for {
+		s.mu.Lock()
+		if len(s.pending) == 0 {
+			s.mu.Unlock()
+			select {
+			case <-s.wake:
+				continue
+			case <-s.done:
+				return
+			}
+		}
+		e := s.pending[0]
+		s.pending = s.pending[1:]
+		s.mu.Unlock()
+
+		select {
+		case s.events <- e:
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// knownVersion is an existing version of a versioned secret
+type knownVersion struct {
+	secret   *corev1.Secret
+	reported bool
+}
+
+// Watcher delivers events to subscribers, whenever a versioned secret reaches
+// a new version. Each version is reported at most once, when it becomes the
+// greatest existing version. Deletions and decorations of existing versions
+// are not reported. Once all versions of a versioned secret are deleted, the
+// versions of a re-created secret with the same name are reported again.
+//
+// Events are queued for each subscriber, so slow subscribers neither block
+// the informer nor other subscribers.
+//
+// With controller-runtime, register it with the informer of the manager's cache:
+//
+//	informer, err := mgr.GetCache().GetInformer(ctx, &corev1.Secret{})
+//	watcher := versionedsecretstore.NewWatcher()
+//	events := watcher.SubscribeGeneric(ctx, namespace, "")
+//	watcher.Register(informer)
+//
+// and watch the events with a `source.Channel`.
+//
+// Plain clientset programs use NewClientsetWatcher and Start.
+//
+// Subscribe before registering or starting, newly registered informers replay
+// the existing versions to the watcher.
+type Watcher struct {
+	mu            sync.Mutex
+	versions      map[string]map[int]*knownVersion
+	subscriptions map[*subscription]struct{}
+	factory       informers.SharedInformerFactory
+}
+
+// NewWatcher returns a Watcher, which needs to be registered with an informer for secrets
+func NewWatcher() *Watcher {
+	return &Watcher{
+		versions:      map[string]map[int]*knownVersion{},
+		subscriptions: map[*subscription]struct{}{},
+	}
+}
+
+// NewClientsetWatcher returns a Watcher, which uses its own informer for
+// versioned secrets in the namespace. Use an empty namespace to watch all
+// namespaces. The informer runs after calling Start.
+func NewClientsetWatcher(clientset kubernetes.Interface, namespace string, resync time.Duration) *Watcher {
+	w := NewWatcher()
+	w.factory = informers.NewSharedInformerFactoryWithOptions(
+		clientset,
+		resync,
+		informers.WithNamespace(namespace),
+		informers.WithTweakListOptions(func(opts *metav1.ListOptions) {
+			opts.LabelSelector = labels.Set{LabelSecretKind: VersionSecretKind}.String()
+		}),
+	)
+	w.Register(w.factory.Core().V1().Secrets().Informer())
+	return w
+}
+
+// Start runs the informer of a clientset watcher until the context is done
+// and waits for its cache to sync. It does nothing for other watchers.
+func (w *Watcher) Start(ctx context.Context) {
+	if w.factory == nil {
+		return
+	}
+	w.factory.Start(ctx.Done())
+	w.factory.WaitForCacheSync(ctx.Done())
+}
+
+// Register adds the watcher as an event handler to the informer
+func (w *Watcher) Register(informer Informer) {
+	informer.AddEventHandler(toolscache.ResourceEventHandlerFuncs{
+		AddFunc:    w.observe,
+		UpdateFunc: func(_, obj interface{}) { w.observe(obj) },
+		DeleteFunc: w.forget,
+	})
+}
+
+// Subscribe returns a channel, which receives an event for each new version
+// of the versioned secret in the namespace. An empty name subscribes to all
+// versioned secrets in the namespace, an empty namespace to all namespaces.
+// The subscription ends when the context is done, the channel is not closed.
+func (w *Watcher) Subscribe(ctx context.Context, namespace string, name string) <-chan VersionEvent {
+	s := &subscription{
+		namespace: namespace,
+		name:      name,
+		events:    make(chan VersionEvent, 1),
+		done:      ctx.Done(),
+		wake:      make(chan struct{}, 1),
+	}
+
+	w.mu.Lock()
+	w.subscriptions[s] = struct{}{}
+	w.mu.Unlock()
+
+	go func() {
+		s.run()
+		w.mu.Lock()
+		delete(w.subscriptions, s)
+		w.mu.Unlock()
+	}()
+
+	return s.events
+}
+
+// SubscribeGeneric is like Subscribe, but returns controller-runtime generic
+// events for the new versions, to be used with a `source.Channel`
+func (w *Watcher) SubscribeGeneric(ctx context.Context, namespace string, name string) <-chan event.GenericEvent {
+	events := w.Subscribe(ctx, namespace, name)
+	generic := make(chan event.GenericEvent)
+
+	go func() {
+		for {
+			select {
+			case e := <-events:
+				select {
+				case generic <- event.GenericEvent{Object: e.Secret}:
+				case <-ctx.Done():
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return generic
+}
+
+// observe records the version and notifies subscribers, if the object is a
+// versioned secret which became the greatest existing version
+func (w *Watcher) observe(obj interface{}) {
+	secret, name, version, ok := versionOf(obj)
+	if !ok {
+		return
+	}
+
+	key := secret.Namespace + "/" + name
+	w.mu.Lock()
+	versions, ok := w.versions[key]
+	if !ok {
+		versions = map[int]*knownVersion{}
+		w.versions[key] = versions
+	}
+	if known, ok := versions[version]; ok {
+		known.secret = secret
+	} else {
+		versions[version] = &knownVersion{secret: secret}
+	}
+	w.notifyLatest(secret.Namespace, name, versions)
+}
+
+// forget removes a deleted version and drops the versioned secret, when no
+// versions remain. Deleting the greatest version reports the remaining
+// greatest version, if it was never reported. This happens if the informer
+// delivers a re-created version before the deletion of the old versions.
+func (w *Watcher) forget(obj interface{}) {
+	if tombstone, ok := obj.(toolscache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+
+	secret, name, version, ok := versionOf(obj)
+	if !ok {
+		return
+	}
+
+	key := secret.Namespace + "/" + name
+	w.mu.Lock()
+	versions, ok := w.versions[key]
+	if !ok {
+		w.mu.Unlock()
+		return
+	}
+	delete(versions, version)
+	if len(versions) == 0 {
+		delete(w.versions, key)
+		w.mu.Unlock()
+		return
+	}
+	w.notifyLatest(secret.Namespace, name, versions)
+}
+
+// notifyLatest queues an event for the greatest version, unless it was
+// reported before. It has to be called with the lock held and unlocks it.
+func (w *Watcher) notifyLatest(namespace string, name string, versions map[int]*knownVersion) {
+	latest := 0
+	for v := range versions {
+		if v > latest {
+			latest = v
+		}
+	}
+
+	known := versions[latest]
+	if known.reported {
+		w.mu.Unlock()
+		return
+	}
+	known.reported = true
+
+	subscriptions := []*subscription{}
+	for s := range w.subscriptions {
+		if (s.namespace == "" || s.namespace == namespace) && (s.name == "" || s.name == name) {
+			subscriptions = append(subscriptions, s)
+		}
+	}
+	w.mu.Unlock()
+
+	e := VersionEvent{
+		Namespace: namespace,
+		Name:      name,
+		Version:   latest,
+		Secret:    known.secret,
+	}
+	for _, s := range subscriptions {
+		s.push(e)
+	}
+}
+
+// versionOf returns the secret, its unversioned name and its version, if the
+// object is a versioned secret
+func versionOf(obj interface{}) (*corev1.Secret, string, int, bool) {
+	secret, ok := obj.(*corev1.Secret)
+	if !ok || !IsVersionedSecret(*secret) {
+		return nil, "", 0, false
+	}
+
+	name := UnversionedName(secret)
+	if name == "" {
+		return nil, "", 0, false
+	}
+
+	version, err := Version(*secret)
+	if err != nil {
+		return nil, "", 0, false
+	}
+	return secret, name, version, true
+}
@@ -0,0 +1,158 @@
+package versionedsecretstore_test
+
+import (
+	"context"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+	toolscache "k8s.io/client-go/tools/cache"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "code.cloudfoundry.org/quarks-utils/pkg/versionedsecretstore"
+)
+
+var _ = Describe("Watcher", func() {
+	var (
+		clientset *fake.Clientset
+		store     VersionedSecretStore
+		watcher   *Watcher
+		ctx       context.Context
+		cancel    context.CancelFunc
+	)
+
+	create := func(name string, data string) {
+		err := store.Create(
+			ctx,
+			"default",
+			"some-owner",
+			types.UID("d3d423b7-a57f-43b0-8305-79d484154e4f"),
+			"some-kind",
+			name,
+			map[string]string{"password": data},
+			nil,
+			map[string]string{},
+			"created by a unit-test",
+		)
+		Expect(err).ToNot(HaveOccurred())
+	}
+
+	BeforeEach(func() {
+		ctx, cancel = context.WithCancel(context.Background())
+		clientset = fake.NewSimpleClientset()
+		store = NewClientsetVersionedSecretStore(clientset)
+		watcher = NewClientsetWatcher(clientset, "default", time.Minute)
+	})
+
+	AfterEach(func() {
+		cancel()
+	})
+
+	It("delivers existing and new versions", func() {
+		create("foo", "1")
+
+		events := watcher.Subscribe(ctx, "default", "foo")
+		watcher.Start(ctx)
+
+		var e VersionEvent
+		Eventually(events).Should(Receive(&e))
+		Expect(e.Name).To(Equal("foo"))
+		Expect(e.Version).To(Equal(1))
+
+		create("bar", "1")
+		create("foo", "2")
+
+		Eventually(events).Should(Receive(&e))
+		Expect(e.Namespace).To(Equal("default"))
+		Expect(e.Name).To(Equal("foo"))
+		Expect(e.Version).To(Equal(2))
+		Expect(e.Secret.Name).To(Equal("foo-v2"))
+	})
+
+	It("does not report decorations of existing versions", func() {
+		events := watcher.Subscribe(ctx, "", "")
+		watcher.Start(ctx)
+
+		create("foo", "1")
+		Eventually(events).Should(Receive())
+
+		Expect(store.Decorate(ctx, "default", "foo", "deployed", "true")).To(Succeed())
+		Consistently(events, "200ms").ShouldNot(Receive())
+	})
+
+	It("reports versions of re-created secrets", func() {
+		events := watcher.Subscribe(ctx, "default", "foo")
+		watcher.Start(ctx)
+
+		create("foo", "1")
+		create("foo", "2")
+		var e VersionEvent
+		Eventually(events).Should(Receive(&e))
+		Eventually(events).Should(Receive(&e))
+		Expect(e.Version).To(Equal(2))
+
+		Expect(store.Delete(ctx, "default", "foo")).To(Succeed())
+		create("foo", "3")
+
+		Eventually(events).Should(Receive(&e))
+		Expect(e.Version).To(Equal(1))
+		Expect(e.Secret.Data["password"]).To(Equal([]byte("3")))
+	})
+
+	It("does not block on slow subscribers", func() {
+		slow := watcher.Subscribe(ctx, "default", "")
+		fast := watcher.Subscribe(ctx, "default", "")
+		watcher.Start(ctx)
+
+		for _, name := range []string{"a", "b", "c"} {
+			create(name, "1")
+		}
+
+		var e VersionEvent
+		for _, name := range []string{"a", "b", "c"} {
+			Eventually(fast).Should(Receive(&e))
+			Expect(e.Name).To(Equal(name))
+		}
+
+		for _, name := range []string{"a", "b", "c"} {
+			Eventually(slow).Should(Receive(&e))
+			Expect(e.Name).To(Equal(name))
+		}
+	})
+
+	It("handles deletions with unknown final state", func() {
+		informer := &fakeInformer{}
+		watcher = NewWatcher()
+		watcher.Register(informer)
+		events := watcher.Subscribe(ctx, "default", "foo")
+
+		create("foo", "1")
+		secret, err := store.Latest(ctx, "default", "foo")
+		Expect(err).ToNot(HaveOccurred())
+
+		informer.handler.OnAdd(secret)
+		Eventually(events).Should(Receive())
+
+		informer.handler.OnDelete(toolscache.DeletedFinalStateUnknown{Key: "default/foo-v1", Obj: secret})
+		informer.handler.OnAdd(secret)
+		Eventually(events).Should(Receive())
+	})
+
+	It("delivers generic events for controller-runtime", func() {
+		events := watcher.SubscribeGeneric(ctx, "default", "")
+		watcher.Start(ctx)
+
+		create("foo", "1")
+		Eventually(events).Should(Receive())
+	})
+})
+
+type fakeInformer struct {
+	handler toolscache.ResourceEventHandler
+}
+
+func (i *fakeInformer) AddEventHandler(handler toolscache.ResourceEventHandler) {
+	i.handler = handler
+}
@@ -0,0 +1,44 @@
+package testing
+
+import (
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"code.cloudfoundry.org/quarks-utils/pkg/versionedsecretstore"
+	"code.cloudfoundry.org/quarks-utils/pkg/versionedsecretstore/fakes"
+)
+
+// NewInMemoryVersionedSecretStore returns a versioned secret store, which
+// keeps all versions in the returned fake clientset. The clientset contains
+// the given objects and can be used to prepare or inspect versions.
+func NewInMemoryVersionedSecretStore(objects ...runtime.Object) (versionedsecretstore.VersionedSecretImpl, *fake.Clientset) {
+	clientset := fake.NewSimpleClientset(objects...)
+	return versionedsecretstore.NewClientsetVersionedSecretStore(clientset), clientset
+}
+
+// NewDelegatingFakeVersionedSecretStore returns a fake, which records all
+// calls and passes them on to the store. Stubbing results replaces the
+// delegation of that method, e.g. to inject errors:
+//
+//	store, _ := testing.NewInMemoryVersionedSecretStore()
+//	fake := testing.NewDelegatingFakeVersionedSecretStore(store)
+//	fake.LatestReturns(nil, errors.New("fake-error"))
+func NewDelegatingFakeVersionedSecretStore(store versionedsecretstore.VersionedSecretStore) *fakes.FakeVersionedSecretStore {
+	return &fakes.FakeVersionedSecretStore{
+		SetSecretReferencesStub: store.SetSecretReferences,
+		CreateStub:              store.Create,
+		CreateWithOwnersStub:    store.CreateWithOwners,
+		CreateWithOptionsStub:   store.CreateWithOptions,
+		GetStub:                 store.Get,
+		LatestStub:              store.Latest,
+		ListStub:                store.List,
+		VersionCountStub:        store.VersionCount,
+		DeleteStub:              store.Delete,
+		RollbackStub:            store.Rollback,
+		PruneStub:               store.Prune,
+		DecorateStub:            store.Decorate,
+		DecorateVersionStub:     store.DecorateVersion,
+		LatestDecoratedStub:     store.LatestDecorated,
+		DecorationHistoryStub:   store.DecorationHistory,
+	}
+}
@@ -0,0 +1,108 @@
+package testing_test
+
+import (
+	"context"
+	"errors"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"code.cloudfoundry.org/quarks-utils/pkg/versionedsecretstore"
+	"code.cloudfoundry.org/quarks-utils/pkg/versionedsecretstore/fakes"
+	"code.cloudfoundry.org/quarks-utils/testing"
+)
+
+var _ = Describe("VersionedSecretStore helpers", func() {
+	var (
+		ctx       context.Context
+		store     versionedsecretstore.VersionedSecretImpl
+		clientset *fake.Clientset
+		fakeStore *fakes.FakeVersionedSecretStore
+	)
+
+	create := func(s versionedsecretstore.VersionedSecretStore, password string) error {
+		return s.CreateWithOptions(ctx, versionedsecretstore.CreateOptions{
+			Namespace:  "default",
+			Name:       "fake-secret",
+			StringData: map[string]string{"password": password},
+		})
+	}
+
+	BeforeEach(func() {
+		ctx = testing.NewContext()
+		store, clientset = testing.NewInMemoryVersionedSecretStore(&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "existing", Namespace: "default"},
+		})
+		fakeStore = testing.NewDelegatingFakeVersionedSecretStore(store)
+	})
+
+	Describe("NewInMemoryVersionedSecretStore", func() {
+		It("keeps versions in the clientset", func() {
+			Expect(create(store, "foo")).To(Succeed())
+			Expect(create(store, "bar")).To(Succeed())
+			Expect(versionedsecretstore.IsSecretIdenticalError(create(store, "bar"))).To(BeTrue())
+
+			secrets, err := clientset.CoreV1().Secrets("default").List(ctx, metav1.ListOptions{})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(secrets.Items).To(HaveLen(3))
+
+			_, err = clientset.CoreV1().Secrets("default").Get(ctx, "fake-secret-v2", metav1.GetOptions{})
+			Expect(err).ToNot(HaveOccurred())
+		})
+	})
+
+	Describe("NewDelegatingFakeVersionedSecretStore", func() {
+		It("delegates to the store", func() {
+			Expect(create(fakeStore, "foo")).To(Succeed())
+			Expect(create(fakeStore, "bar")).To(Succeed())
+
+			latest, err := store.Latest(ctx, "default", "fake-secret")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(latest.Data["password"])).To(Equal("bar"))
+
+			latest, err = fakeStore.Latest(ctx, "default", "fake-secret")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(latest.Name).To(Equal("fake-secret-v2"))
+		})
+
+		It("records calls", func() {
+			Expect(create(fakeStore, "foo")).To(Succeed())
+			_, err := fakeStore.Get(ctx, "default", "fake-secret", 1)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fakeStore.CreateWithOptionsCallCount()).To(Equal(1))
+			_, opts := fakeStore.CreateWithOptionsArgsForCall(0)
+			Expect(opts.Name).To(Equal("fake-secret"))
+
+			Expect(fakeStore.GetCallCount()).To(Equal(1))
+			_, namespace, name, version := fakeStore.GetArgsForCall(0)
+			Expect(namespace).To(Equal("default"))
+			Expect(name).To(Equal("fake-secret"))
+			Expect(version).To(Equal(1))
+		})
+
+		It("returns injected errors instead of delegating", func() {
+			Expect(create(fakeStore, "foo")).To(Succeed())
+
+			fakeStore.LatestReturns(nil, errors.New("fake-error"))
+			_, err := fakeStore.Latest(ctx, "default", "fake-secret")
+			Expect(err).To(MatchError("fake-error"))
+
+			fakeStore.CreateWithOptionsReturnsOnCall(1, errors.New("fake-create-error"))
+			Expect(create(fakeStore, "bar")).To(MatchError("fake-create-error"))
+
+			n, err := store.VersionCount(ctx, "default", "fake-secret")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(n).To(Equal(1))
+
+			// other methods still delegate
+			n, err = fakeStore.VersionCount(ctx, "default", "fake-secret")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(n).To(Equal(1))
+		})
+	})
+})